@@ -30,4 +30,20 @@ version: {{ .Values.xposer.labels.version }}
 chart: "{{ .Chart.Name }}-{{ .Chart.Version }}"
 release: {{ .Release.Name | quote }}
 heritage: {{ .Release.Service | quote }}
-{{- end -}}
\ No newline at end of file
+{{- end -}}
+{{/*
+Whether services are watched in more than one namespace, which needs a ClusterRole: in all namespaces, in the
+namespaces selected by labels or in a list of namespaces.
+*/}}
+{{- define "xposer-watch-cluster-wide" -}}
+{{- if or .Values.xposer.watchGlobally .Values.xposer.watchNamespaceSelector (contains "," .Values.xposer.watchNamespaces) -}}
+true
+{{- end -}}
+{{- end -}}
+
+{{/*
+The only namespace whose services are watched, when services are not watched cluster wide.
+*/}}
+{{- define "xposer-watch-namespace" -}}
+{{- default .Release.Namespace .Values.xposer.watchNamespaces -}}
+{{- end -}}
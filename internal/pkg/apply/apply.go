@@ -0,0 +1,180 @@
+package apply
+
+import (
+	"encoding/json"
+
+	osV1 "github.com/openshift/api/route/v1"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// IngressPatch returns the strategic merge patch which applies the desired Ingress onto the current one, or nil if
+// the current Ingress is up to date. Like kubectl apply, the desired Ingress is recorded in an annotation, so that
+// the fields Xposer applied before and does not want anymore are removed, while the fields set by others, e.g. the
+// annotations of cert-manager or external-dns, are kept. The patch fails with a conflict if the Ingress changed
+// since it was read
+func IngressPatch(desired *v1beta1.Ingress, current *v1beta1.Ingress) ([]byte, error) {
+	desired = desired.DeepCopy()
+	desired.ResourceVersion = ""
+	original, modified, err := recordLastApplied(desired, &desired.ObjectMeta, current.ObjectMeta)
+	if err != nil {
+		return nil, err
+	}
+
+	currentJSON, err := toJSON(current)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := strategicpatch.NewPatchMetaFromStruct(current)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := strategicpatch.CreateThreeWayMergePatch(original, modified, currentJSON, schema, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return withResourceVersion(patch, current.ResourceVersion)
+}
+
+// RoutePatch returns the JSON merge patch which applies the desired Route onto the current one, or nil if the
+// current Route is up to date, see IngressPatch. Routes have no strategic merge metadata, so lists are replaced
+func RoutePatch(desired *osV1.Route, current *osV1.Route) ([]byte, error) {
+	desired = desired.DeepCopy()
+	desired.ResourceVersion = ""
+	original, modified, err := recordLastApplied(desired, &desired.ObjectMeta, current.ObjectMeta)
+	if err != nil {
+		return nil, err
+	}
+
+	currentJSON, err := toJSON(current)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(original, modified, currentJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return withResourceVersion(patch, current.ResourceVersion)
+}
+
+// recordLastApplied records the desired object in its last applied annotation, and returns the object applied
+// before, read from the current object, and the desired object as JSON
+func recordLastApplied(desired interface{}, desiredMeta *meta_v1.ObjectMeta, currentMeta meta_v1.ObjectMeta) ([]byte, []byte, error) {
+	var original []byte
+	if lastApplied := currentMeta.Annotations[constants.LAST_APPLIED_ANNOTATION]; lastApplied != "" {
+		original = []byte(lastApplied)
+	}
+
+	err := RecordLastApplied(desired, desiredMeta)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	modified, err := toJSON(desired)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return original, modified, nil
+}
+
+// RecordLastApplied records an object, without its own last applied annotation, in its last applied annotation.
+// Objects are created with it, so that their first update knows what was applied
+func RecordLastApplied(obj interface{}, objectMeta *meta_v1.ObjectMeta) error {
+	annotations := make(map[string]string, len(objectMeta.Annotations)+1)
+	for key, value := range objectMeta.Annotations {
+		if key != constants.LAST_APPLIED_ANNOTATION {
+			annotations[key] = value
+		}
+	}
+	objectMeta.Annotations = annotations
+
+	lastApplied, err := toJSON(obj)
+	if err != nil {
+		return err
+	}
+	annotations[constants.LAST_APPLIED_ANNOTATION] = string(lastApplied)
+
+	return nil
+}
+
+// toJSON marshals the object without null values, which Go marshals for unset structs like creationTimestamp, and
+// which would otherwise remove those fields from the current object
+func toJSON(obj interface{}) ([]byte, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]interface{}
+	err = json.Unmarshal(data, &object)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(removeNulls(object))
+}
+
+func removeNulls(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, child := range typed {
+			if child == nil {
+				delete(typed, key)
+			} else {
+				typed[key] = removeNulls(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range typed {
+			typed[i] = removeNulls(child)
+		}
+	}
+
+	return value
+}
+
+// removeEmpty removes the empty objects, which JSON merge patches contain for unchanged parents of ignored fields
+// and which change nothing when applied
+func removeEmpty(object map[string]interface{}) {
+	for key, child := range object {
+		if childObject, ok := child.(map[string]interface{}); ok {
+			removeEmpty(childObject)
+			if len(childObject) == 0 {
+				delete(object, key)
+			}
+		}
+	}
+}
+
+// withResourceVersion adds the resource version of the patched object to a patch, so that the API server refuses
+// it with a conflict if the object changed in between. An empty patch means there is nothing to change, nil is
+// returned for it
+func withResourceVersion(patch []byte, resourceVersion string) ([]byte, error) {
+	var object map[string]interface{}
+	err := json.Unmarshal(patch, &object)
+	if err != nil {
+		return nil, err
+	}
+	removeEmpty(object)
+	if len(object) == 0 {
+		return nil, nil
+	}
+
+	metadata, ok := object["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = make(map[string]interface{})
+		object["metadata"] = metadata
+	}
+	metadata["resourceVersion"] = resourceVersion
+
+	return json.Marshal(object)
+}
@@ -0,0 +1,180 @@
+package apply
+
+import (
+	"encoding/json"
+	"testing"
+
+	osV1 "github.com/openshift/api/route/v1"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+func newIngress(host string, annotations map[string]string) *v1beta1.Ingress {
+	return &v1beta1.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "team", Annotations: annotations},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{{Host: host}},
+		},
+	}
+}
+
+// applied returns the Ingress as it is after Xposer created it and others changed it
+func applied(t *testing.T, applied *v1beta1.Ingress, changes func(*v1beta1.Ingress)) *v1beta1.Ingress {
+	current := applied.DeepCopy()
+	if err := RecordLastApplied(current, &current.ObjectMeta); err != nil {
+		t.Fatalf("RecordLastApplied() error = %v", err)
+	}
+	current.ResourceVersion = "7"
+	current.CreationTimestamp = meta_v1.Now()
+	current.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+	if changes != nil {
+		changes(current)
+	}
+
+	return current
+}
+
+func TestIngressPatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		applied   *v1beta1.Ingress
+		changes   func(*v1beta1.Ingress)
+		desired   *v1beta1.Ingress
+		wantPatch bool
+		want      *v1beta1.Ingress
+	}{
+		{
+			name:    "unchanged ingress should not be patched",
+			applied: newIngress("app.example.com", map[string]string{"a": "1"}),
+			desired: newIngress("app.example.com", map[string]string{"a": "1"}),
+		},
+		{
+			name:    "annotations added by others should be kept",
+			applied: newIngress("app.example.com", map[string]string{"a": "1"}),
+			changes: func(current *v1beta1.Ingress) {
+				current.Annotations["cert-manager.io/issuer"] = "letsencrypt"
+			},
+			desired: newIngress("app.example.com", map[string]string{"a": "1"}),
+		},
+		{
+			name:      "changed host should be applied",
+			applied:   newIngress("app.example.com", nil),
+			desired:   newIngress("app.other.com", nil),
+			wantPatch: true,
+			want:      newIngress("app.other.com", nil),
+		},
+		{
+			name:    "annotation not wanted anymore should be removed, others kept",
+			applied: newIngress("app.example.com", map[string]string{"a": "1", "b": "2"}),
+			changes: func(current *v1beta1.Ingress) {
+				current.Annotations["external-dns"] = "true"
+			},
+			desired:   newIngress("app.example.com", map[string]string{"a": "1"}),
+			wantPatch: true,
+			want:      newIngress("app.example.com", map[string]string{"a": "1", "external-dns": "true"}),
+		},
+		{
+			name:    "forwarded annotation changed by others should be reverted",
+			applied: newIngress("app.example.com", map[string]string{"a": "1"}),
+			changes: func(current *v1beta1.Ingress) {
+				current.Annotations["a"] = "2"
+			},
+			desired:   newIngress("app.example.com", map[string]string{"a": "1"}),
+			wantPatch: true,
+			want:      newIngress("app.example.com", map[string]string{"a": "1"}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := applied(t, tt.applied, tt.changes)
+
+			patch, err := IngressPatch(tt.desired, current)
+			if err != nil {
+				t.Fatalf("IngressPatch() error = %v", err)
+			}
+			if (patch != nil) != tt.wantPatch {
+				t.Fatalf("IngressPatch() = %s, wantPatch %v", patch, tt.wantPatch)
+			}
+			if patch == nil {
+				return
+			}
+
+			currentJSON, _ := json.Marshal(current)
+			patchedJSON, err := strategicpatch.StrategicMergePatch(currentJSON, patch, &v1beta1.Ingress{})
+			if err != nil {
+				t.Fatalf("StrategicMergePatch() error = %v", err)
+			}
+			patched := &v1beta1.Ingress{}
+			if err := json.Unmarshal(patchedJSON, patched); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			if patched.ResourceVersion != current.ResourceVersion {
+				t.Errorf("IngressPatch() should keep the resource version as precondition")
+			}
+			if patched.Spec.Rules[0].Host != tt.want.Spec.Rules[0].Host {
+				t.Errorf("IngressPatch() host = %v, want %v", patched.Spec.Rules[0].Host, tt.want.Spec.Rules[0].Host)
+			}
+			if len(patched.Status.LoadBalancer.Ingress) != 1 {
+				t.Errorf("IngressPatch() should keep the status")
+			}
+			if _, ok := patched.Annotations[constants.LAST_APPLIED_ANNOTATION]; !ok {
+				t.Errorf("IngressPatch() should record the applied Ingress")
+			}
+			if len(patched.Annotations)-1 != len(tt.want.Annotations) {
+				t.Errorf("IngressPatch() annotations = %v, want %v", patched.Annotations, tt.want.Annotations)
+			}
+			for key, value := range tt.want.Annotations {
+				if patched.Annotations[key] != value {
+					t.Errorf("IngressPatch() annotations = %v, want %v", patched.Annotations, tt.want.Annotations)
+				}
+			}
+
+			// Applying the same desired Ingress again must be a no-op
+			again, err := IngressPatch(tt.desired, patched)
+			if err != nil || again != nil {
+				t.Errorf("IngressPatch() on patched Ingress = %s, %v, want no patch", again, err)
+			}
+		})
+	}
+}
+
+func TestRoutePatch(t *testing.T) {
+	newRoute := func(host string) *osV1.Route {
+		return &osV1.Route{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "team"},
+			Spec: osV1.RouteSpec{
+				Host: host,
+				To:   osV1.RouteTargetReference{Kind: "Service", Name: "app"},
+			},
+		}
+	}
+
+	current := newRoute("app.example.com")
+	if err := RecordLastApplied(current, &current.ObjectMeta); err != nil {
+		t.Fatalf("RecordLastApplied() error = %v", err)
+	}
+	weight := int32(100)
+	current.Spec.To.Weight = &weight
+
+	patch, err := RoutePatch(newRoute("app.example.com"), current)
+	if err != nil || patch != nil {
+		t.Errorf("RoutePatch() on unchanged Route = %s, %v, want no patch", patch, err)
+	}
+
+	patch, err = RoutePatch(newRoute("app.other.com"), current)
+	if err != nil || patch == nil {
+		t.Fatalf("RoutePatch() on changed Route = %s, %v, want a patch", patch, err)
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(patch, &object); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	spec := object["spec"].(map[string]interface{})
+	if spec["host"] != "app.other.com" || spec["to"] != nil {
+		t.Errorf("RoutePatch() = %s, should only change the host", patch)
+	}
+}
@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	routeClient "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stakater/Xposer/internal/pkg/configmaps"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/ownership"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CleanupOptions holds the settings of the cleanup command
+type CleanupOptions struct {
+	Namespace  string
+	DryRun     bool
+	Kubeconfig string
+	Context    string
+}
+
+// cleanupItem is an object generated by Xposer which is deleted by the cleanup command. For a ConfigMap, Keys are
+// the keys published by Xposer, and the ConfigMap is only updated to remove them if Kept, as it holds other keys
+type cleanupItem struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Owner     string
+	Keys      []string
+	Kept      bool
+	delete    func() error
+}
+
+// NewCleanupCommand creates the command which deletes every object Xposer generated
+func NewCleanupCommand() *cobra.Command {
+	options := &CleanupOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Delete the Ingresses, Routes and ConfigMaps generated by Xposer",
+		Long: "Delete the Ingresses, Routes and ConfigMaps generated by Xposer. Only objects carrying the " +
+			constants.MANAGED_BY_LABEL + "=" + constants.CONTROLLER_NAME + " label are deleted, user created objects are " +
+			"left untouched. Stop Xposer first, otherwise it generates the objects again",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := bindEnvironment(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			kubeClient, osClient, _, err := createClients(options.Kubeconfig, options.Context)
+			if err != nil {
+				return err
+			}
+
+			var routesGetter routeClient.RoutesGetter
+			if osClient != nil {
+				routesGetter = osClient
+			}
+
+			return runCleanup(cmd.OutOrStdout(), kubeClient, routesGetter, options)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "Namespace to clean up, all namespaces if empty")
+	cmd.Flags().BoolVar(&options.DryRun, "dry-run", false, "Only show the objects which would be deleted")
+	cmd.Flags().StringVar(&options.Kubeconfig, "kubeconfig", "", "Path of the kubeconfig file, the in-cluster config is used if empty")
+	cmd.Flags().StringVar(&options.Context, "context", "", "Kubeconfig context to use")
+
+	return cmd
+}
+
+func runCleanup(out io.Writer, clientset kubernetes.Interface, routesGetter routeClient.RoutesGetter, options *CleanupOptions) error {
+	items, err := planCleanup(clientset, routesGetter, options.Namespace)
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintln(out, "Nothing to clean up")
+		return nil
+	}
+
+	fmt.Fprintf(out, "Objects generated by Xposer:\n")
+	for _, item := range items {
+		detail := ""
+		if item.Owner != "" {
+			detail = " for Service " + item.Owner
+		}
+		if len(item.Keys) > 0 {
+			detail = " keys " + strings.Join(item.Keys, ", ")
+		}
+		if item.Kept {
+			detail += " (kept, it holds other keys)"
+		}
+		fmt.Fprintf(out, "  - %v %v/%v%v\n", item.Kind, item.Namespace, item.Name, detail)
+	}
+
+	if options.DryRun {
+		fmt.Fprintf(out, "Dry run, %d object(s) would be deleted or updated\n", len(items))
+		return nil
+	}
+
+	failed := 0
+	for _, item := range items {
+		err := item.delete()
+		if err != nil {
+			logrus.Errorf("Can not delete %v %v/%v: %v", item.Kind, item.Namespace, item.Name, err)
+			failed++
+			continue
+		}
+		if item.Kept {
+			fmt.Fprintf(out, "Removed %d key(s) from %v %v/%v\n", len(item.Keys), item.Kind, item.Namespace, item.Name)
+		} else {
+			fmt.Fprintf(out, "Deleted %v %v/%v\n", item.Kind, item.Namespace, item.Name)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d object(s) could not be deleted or updated", failed, len(items))
+	}
+
+	return nil
+}
+
+// planCleanup finds the objects carrying Xposer's ownership markers in the given namespace, all namespaces if empty.
+// routesGetter can be nil on Kubernetes
+func planCleanup(clientset kubernetes.Interface, routesGetter routeClient.RoutesGetter, namespace string) ([]cleanupItem, error) {
+	items := []cleanupItem{}
+	listOptions := meta_v1.ListOptions{LabelSelector: ownership.Selector()}
+
+	// Generated objects of all namespaces are listed, as the keys of their owners may be published in the namespace
+	ingressList, err := clientset.ExtensionsV1beta1().Ingresses("").List(listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("can not list Ingresses: %v", err)
+	}
+	owners := newCleanupOwners()
+	for _, ingress := range ingressList.Items {
+		owners.add(ingress.Annotations[constants.OWNER_ANNOTATION])
+		if namespace != "" && ingress.Namespace != namespace {
+			continue
+		}
+		ingressNamespace, ingressName := ingress.Namespace, ingress.Name
+		items = append(items, cleanupItem{
+			Kind:      "Ingress",
+			Namespace: ingressNamespace,
+			Name:      ingressName,
+			Owner:     ingress.Annotations[constants.OWNER_ANNOTATION],
+			delete: func() error {
+				return clientset.ExtensionsV1beta1().Ingresses(ingressNamespace).Delete(ingressName, &meta_v1.DeleteOptions{})
+			},
+		})
+	}
+
+	if routesGetter != nil {
+		routeList, err := routesGetter.Routes("").List(listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("can not list Routes: %v", err)
+		}
+		for _, route := range routeList.Items {
+			owners.add(route.Annotations[constants.OWNER_ANNOTATION])
+			if namespace != "" && route.Namespace != namespace {
+				continue
+			}
+			routeNamespace, routeName := route.Namespace, route.Name
+			items = append(items, cleanupItem{
+				Kind:      "Route",
+				Namespace: routeNamespace,
+				Name:      routeName,
+				Owner:     route.Annotations[constants.OWNER_ANNOTATION],
+				delete: func() error {
+					return routesGetter.Routes(routeNamespace).Delete(routeName, &meta_v1.DeleteOptions{})
+				},
+			})
+		}
+	}
+
+	// The keys published by Xposer in the xposer configmap are those of the services owning the generated objects, and
+	// of the services publishing their URLs, whose generated objects may already be deleted. Users may have added keys
+	// of their own, the configmap is only deleted if no key is left
+	serviceList, err := clientset.CoreV1().Services("").List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("can not list Services: %v", err)
+	}
+	for i := range serviceList.Items {
+		service := &serviceList.Items[i]
+		exposeIngressURL := ingresses.GetExposeIngressURL(service)
+		if exposeIngressURL == constants.GLOBALLY || exposeIngressURL == constants.LOCALLY {
+			owners.add(service.Namespace + "/" + service.Name)
+		}
+	}
+
+	configMapList, err := clientset.CoreV1().ConfigMaps(namespace).List(listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("can not list ConfigMaps: %v", err)
+	}
+	for _, configMap := range configMapList.Items {
+		if configMap.Name != constants.XPOSER_CONFIGMAP {
+			continue
+		}
+		keys := xposerKeys(configMap.Data, owners.services)
+		if len(keys) == 0 && len(configMap.Data) > 0 {
+			continue
+		}
+		configMapNamespace := configMap.Namespace
+		items = append(items, cleanupItem{
+			Kind:      "ConfigMap",
+			Namespace: configMapNamespace,
+			Name:      configMap.Name,
+			Keys:      keys,
+			Kept:      len(keys) < len(configMap.Data),
+			delete: func() error {
+				return removeConfigMapKeys(clientset, configMapNamespace, keys)
+			},
+		})
+	}
+
+	return items, nil
+}
+
+// cleanupOwners collects the services whose keys are removed from xposer configmaps, each of them once
+type cleanupOwners struct {
+	services []*v1.Service
+	seen     map[string]bool
+}
+
+func newCleanupOwners() *cleanupOwners {
+	return &cleanupOwners{services: []*v1.Service{}, seen: make(map[string]bool)}
+}
+
+// add adds the service given as namespace/name, as in the owner annotation, malformed owners are ignored
+func (o *cleanupOwners) add(owner string) {
+	parts := strings.SplitN(owner, "/", 2)
+	if len(parts) != 2 || o.seen[owner] {
+		return
+	}
+	o.seen[owner] = true
+	o.services = append(o.services, &v1.Service{ObjectMeta: meta_v1.ObjectMeta{Namespace: parts[0], Name: parts[1]}})
+}
+
+// xposerKeys returns the sorted keys of the xposer configmap data which hold URLs of the given services
+func xposerKeys(data map[string]string, services []*v1.Service) []string {
+	keys := []string{}
+	for key := range data {
+		for _, service := range services {
+			if configmaps.IsServiceKey(key, service) {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// removeConfigMapKeys removes the keys from the xposer configmap of a namespace, which is deleted if no key is left
+func removeConfigMapKeys(clientset kubernetes.Interface, namespace string, keys []string) error {
+	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(constants.XPOSER_CONFIGMAP, meta_v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		delete(configMap.Data, key)
+	}
+	if len(configMap.Data) == 0 {
+		return clientset.CoreV1().ConfigMaps(namespace).Delete(constants.XPOSER_CONFIGMAP, &meta_v1.DeleteOptions{})
+	}
+
+	_, err = clientset.CoreV1().ConfigMaps(namespace).Update(configMap)
+	return err
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/configmaps"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRunCleanup(t *testing.T) {
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+
+	ingressInfo, err := ingresses.CreateIngressInfo(createExposedService("app", nil), nil, conf)
+	if err != nil {
+		t.Fatalf("CreateIngressInfo() error = %v", err)
+	}
+
+	// web publishes its URL globally, its Ingress is already deleted
+	web := createExposedService("web", map[string]string{constants.FORWARD_ANNOTATION: "exposeIngressUrl: globally"})
+
+	tests := []struct {
+		name              string
+		namespace         string
+		dryRun            bool
+		wantIngresses     []string
+		wantConfigMapsLen int
+		wantSharedData    map[string]string
+	}{
+		{
+			name:              "dry run should not delete anything",
+			dryRun:            true,
+			wantIngresses:     []string{"app", "user"},
+			wantConfigMapsLen: 5,
+			wantSharedData: map[string]string{"app-team": "app.team.example.com", "app-team.internal": "app.internal.example.com",
+				"web-team": "web.team.example.com", "custom": "value"},
+		},
+		{
+			name:              "only objects and keys generated by Xposer should be deleted",
+			wantIngresses:     []string{"user"},
+			wantConfigMapsLen: 3,
+			wantSharedData:    map[string]string{"custom": "value"},
+		},
+		{
+			name:              "keys of services in other namespaces should be removed from the cleaned up namespace",
+			namespace:         "shared",
+			wantIngresses:     []string{"app", "user"},
+			wantConfigMapsLen: 5,
+			wantSharedData:    map[string]string{"custom": "value"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(
+				web,
+				ingresses.CreateWithTLSFromIngressInfo(ingressInfo),
+				&v1beta1.Ingress{ObjectMeta: meta_v1.ObjectMeta{Name: "user", Namespace: "team"}},
+				configmaps.CreateConfigMapObject("team", map[string]string{"app-team": "app.team.example.com"}),
+				&v1.ConfigMap{ObjectMeta: meta_v1.ObjectMeta{Name: "xposer", Namespace: "other"}},
+				&v1.ConfigMap{ObjectMeta: meta_v1.ObjectMeta{Name: "user", Namespace: "team"}},
+				configmaps.CreateConfigMapObject("shared", map[string]string{
+					"app-team":          "app.team.example.com",
+					"app-team.internal": "app.internal.example.com",
+					"web-team":          "web.team.example.com",
+					"custom":            "value",
+				}),
+				configmaps.CreateConfigMapObject("empty", nil),
+			)
+
+			out := &bytes.Buffer{}
+			err := runCleanup(out, clientset, nil, &CleanupOptions{Namespace: tt.namespace, DryRun: tt.dryRun})
+			if err != nil {
+				t.Fatalf("runCleanup() error = %v", err)
+			}
+
+			ingressList, _ := clientset.ExtensionsV1beta1().Ingresses("").List(meta_v1.ListOptions{})
+			names := []string{}
+			for _, ingress := range ingressList.Items {
+				names = append(names, ingress.Name)
+			}
+			if len(names) != len(tt.wantIngresses) {
+				t.Errorf("runCleanup() left Ingresses %v, want %v", names, tt.wantIngresses)
+			}
+
+			configMapList, _ := clientset.CoreV1().ConfigMaps("").List(meta_v1.ListOptions{})
+			if len(configMapList.Items) != tt.wantConfigMapsLen {
+				t.Errorf("runCleanup() left %d ConfigMaps, want %d", len(configMapList.Items), tt.wantConfigMapsLen)
+			}
+
+			shared, err := clientset.CoreV1().ConfigMaps("shared").Get("xposer", meta_v1.GetOptions{})
+			if err != nil {
+				t.Fatalf("runCleanup() deleted the ConfigMap holding user keys: %v", err)
+			}
+			if !reflect.DeepEqual(shared.Data, tt.wantSharedData) {
+				t.Errorf("runCleanup() left keys %v, want %v", shared.Data, tt.wantSharedData)
+			}
+		})
+	}
+}
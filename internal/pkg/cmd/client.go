@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+
+	routeClient "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/pkg/kube"
+	"k8s.io/client-go/kubernetes"
+)
+
+// createClients creates the clients used by the commands which query the cluster. The route client is nil, and the
+// cluster type is kubernetes, if the cluster is not OpenShift
+func createClients(kubeconfig string, context string) (*kubernetes.Clientset, *routeClient.RouteV1Client, string, error) {
+	cfg, err := kube.GetConfig(kubeconfig, context)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("can not get kubernetes config: %v", err)
+	}
+
+	kubeClient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("can not create kubernetes client: %v", err)
+	}
+
+	if !kube.IsOpenShift(kubeClient) {
+		return kubeClient, nil, constants.KUBERNETES, nil
+	}
+
+	osClient, err := routeClient.NewForConfig(cfg)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("can not create OpenShift client: %v", err)
+	}
+
+	return kubeClient, osClient, constants.OPENSHIFT, nil
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/permissions"
+	"github.com/stakater/Xposer/internal/pkg/scope"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NewDoctorCommand creates the command which checks the environment and permissions of Xposer
+func NewDoctorCommand() *cobra.Command {
+	options := &XposerOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the configuration, cluster and RBAC permissions Xposer needs with the given settings",
+		Long: "Check the configuration, cluster and RBAC permissions Xposer needs with the given settings. Run it with " +
+			"the service account of Xposer, e.g. in its pod or with kubectl --as, to find missing Role or ClusterRole rules",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := bindEnvironment(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			return runDoctor(cmd, options)
+		},
+	}
+	options.AddFlags(cmd.Flags())
+	config.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func runDoctor(cmd *cobra.Command, options *XposerOptions) error {
+	out := cmd.OutOrStdout()
+	problems := 0
+
+	conf, err := config.NewLoader(options.ConfigFilePath, cmd.Flags()).Load()
+	configValid := err == nil
+	if err != nil {
+		fmt.Fprintf(out, "Configuration %v: invalid: %v\n", options.ConfigFilePath, err)
+		problems++
+	} else {
+		fmt.Fprintf(out, "Configuration %v: valid\n", options.ConfigFilePath)
+	}
+
+	kubeClient, _, clusterType, err := createClients(options.Kubeconfig, options.Context)
+	if err != nil {
+		fmt.Fprintf(out, "Cluster: %v\n", err)
+		return fmt.Errorf("can not connect to the cluster")
+	}
+	fmt.Fprintf(out, "Cluster type: %v\n", clusterType)
+
+	watched, err := options.Scope()
+	if err != nil {
+		return err
+	}
+	namespace := watched.Namespace()
+	fmt.Fprintf(out, "Watched namespaces: %v\n", watched)
+
+	// Without a valid configuration the exposed services are not known, and global publishing stays optional
+	publishGlobally := false
+	if configValid {
+		publishGlobally, err = publishesGlobally(kubeClient, conf, watched)
+		if err != nil {
+			fmt.Fprintf(out, "Exposed services: %v\n", err)
+		} else {
+			fmt.Fprintf(out, "Exposed services publish URLs globally: %v\n", publishGlobally)
+		}
+	}
+
+	results, err := permissions.Check(kubeClient, permissions.Required(namespace, clusterType, publishGlobally))
+	if err != nil {
+		return err
+	}
+	problems += printPermissions(out, results)
+
+	if problems > 0 {
+		return fmt.Errorf("found %d problem(s)", problems)
+	}
+
+	return nil
+}
+
+// publishesGlobally returns true if any service exposed in the watched namespaces uses exposeIngressUrl: globally,
+// which needs the permissions to write xposer configmaps in all namespaces
+func publishesGlobally(clientset kubernetes.Interface, conf config.Configuration, watched scope.Scope) (bool, error) {
+	selection, err := config.NewSelection(conf)
+	if err != nil {
+		return false, err
+	}
+
+	serviceList, err := clientset.CoreV1().Services(watched.Namespace()).List(meta_v1.ListOptions{
+		LabelSelector: selection.ServiceSelector(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("can not list Services: %v", err)
+	}
+
+	namespaces := map[string]*v1.Namespace{}
+	if watched.NeedsNamespace() || selection.NeedsNamespace() {
+		namespaces = listNamespaces(clientset)
+	}
+
+	for i := range serviceList.Items {
+		service := &serviceList.Items[i]
+		namespace := namespaces[service.Namespace]
+		if watched.Includes(service.Namespace, namespace) && selection.Exposes(service, namespace) &&
+			ingresses.GetExposeIngressURL(service) == constants.GLOBALLY {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// printPermissions prints every checked permission, and returns the number of missing required permissions
+func printPermissions(out io.Writer, results []permissions.Result) int {
+	missingRequired := 0
+
+	fmt.Fprintln(out, "Permissions:")
+	writer := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "  STATUS\tPERMISSION\tNEEDED FOR")
+	for _, result := range results {
+		status := "OK"
+		if !result.Allowed && result.Optional {
+			status = "MISSING (optional)"
+		} else if !result.Allowed {
+			status = "MISSING"
+			missingRequired++
+		}
+		fmt.Fprintf(writer, "  %v\t%v\t%v\n", status, result.Permission, result.Reason)
+	}
+	writer.Flush()
+
+	return missingRequired
+}
+
+// checkPermissions logs the permissions Xposer is missing at startup, it does not stop Xposer as RBAC rules may
+// still be applied
+func checkPermissions(clientset kubernetes.Interface, conf config.Configuration, watched scope.Scope, clusterType string) {
+	publishGlobally, err := publishesGlobally(clientset, conf, watched)
+	if err != nil {
+		logrus.Warnf("Can not check whether exposed services publish URLs globally: %v", err)
+	}
+
+	results, err := permissions.Check(clientset, permissions.Required(watched.Namespace(), clusterType, publishGlobally))
+	if err != nil {
+		logrus.Warnf("Can not check permissions: %v", err)
+		return
+	}
+
+	for _, result := range permissions.Missing(results) {
+		if result.Optional {
+			logrus.Warnf("Missing permission to %v, needed for %v", result.Permission, result.Reason)
+		} else {
+			logrus.Errorf("Missing permission to %v, needed to %v", result.Permission, result.Reason)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/scope"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPublishesGlobally(t *testing.T) {
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+	globally := map[string]string{constants.FORWARD_ANNOTATION: "exposeIngressUrl: globally"}
+
+	notExposed := createExposedService("not-exposed", globally)
+	notExposed.Labels = nil
+
+	tests := []struct {
+		name     string
+		services []runtime.Object
+		names    []string
+		want     bool
+	}{
+		{"exposed service publishing globally", []runtime.Object{createExposedService("app", globally)}, nil, true},
+		{"exposed service publishing locally",
+			[]runtime.Object{createExposedService("app", map[string]string{constants.FORWARD_ANNOTATION: "exposeIngressUrl: locally"})}, nil, false},
+		{"service publishing globally which is not exposed", []runtime.Object{notExposed}, nil, false},
+		{"service publishing globally outside the watched namespaces",
+			[]runtime.Object{createExposedService("app", globally)}, []string{"other"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watched, err := scope.New(tt.names, "")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got, err := publishesGlobally(fake.NewSimpleClientset(tt.services...), conf, watched)
+			if err != nil {
+				t.Fatalf("publishesGlobally() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("publishesGlobally() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/scope"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ExplainOptions holds the settings of the explain command
+type ExplainOptions struct {
+	ServiceFilePath      string
+	ConfigFilePath       string
+	Namespace            string
+	NamespaceLabels      []string
+	NamespaceAnnotations []string
+	Kubeconfig           string
+	Context              string
+	Output               string
+}
+
+// ServiceExplanation lists the effective settings used to expose a service on one of its tiers
+type ServiceExplanation struct {
+	Service   string              `json:"service"`
+	Namespace string              `json:"namespace"`
+	Tier      string              `json:"tier"`
+	Settings  []ingresses.Setting `json:"settings"`
+}
+
+// NewExplainCommand creates the command which explains where every setting used to expose a Service comes from
+func NewExplainCommand() *cobra.Command {
+	options := &ExplainOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "explain [SERVICE]",
+		Short: "Show every setting used to expose a Service, with its source and template",
+		Long: "Show every setting used to expose a Service, with its source (default, file, flag, tier, " +
+			"service annotation or derived) and its template before rendering. The Service is read from the cluster, " +
+			"or from a file given with --filename",
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := bindEnvironment(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			// Keep the explanation readable, only problems are logged
+			logrus.SetLevel(logrus.WarnLevel)
+			return runExplain(cmd, args, options)
+		},
+	}
+	cmd.Flags().StringVarP(&options.ServiceFilePath, "filename", "f", "", "File containing the Service(s) to explain, - for stdin")
+	cmd.Flags().StringVar(&options.ConfigFilePath, "config", "configs/config.yaml", "Path of the configuration file")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "default", "Namespace of the Service")
+	cmd.Flags().StringArrayVar(&options.NamespaceLabels, "namespace-labels", nil, "Label of the Service namespace as key=value when using --filename, may be repeated")
+	cmd.Flags().StringArrayVar(&options.NamespaceAnnotations, "namespace-annotations", nil, "Annotation of the Service namespace as key=value when using --filename, which templates read as .NamespaceAnnotations, may be repeated")
+	cmd.Flags().StringVar(&options.Kubeconfig, "kubeconfig", "", "Path of the kubeconfig file, the in-cluster config is used if empty")
+	cmd.Flags().StringVar(&options.Context, "context", "", "Kubeconfig context to use")
+	cmd.Flags().StringVarP(&options.Output, "output", "o", "table", "Output format, one of: table, json, yaml")
+	config.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func runExplain(cmd *cobra.Command, args []string, options *ExplainOptions) error {
+	if options.Output != "table" && options.Output != "json" && options.Output != "yaml" {
+		return fmt.Errorf("unknown output format %q, must be one of: table, json, yaml", options.Output)
+	}
+	if (len(args) == 0) == (options.ServiceFilePath == "") {
+		return fmt.Errorf("either a Service name or --filename must be given")
+	}
+
+	conf, sources, err := config.NewLoader(options.ConfigFilePath, cmd.Flags()).Explain()
+	if err != nil {
+		return err
+	}
+
+	explanations := []ServiceExplanation{}
+	if options.ServiceFilePath != "" {
+		namespaceLabels, err := parseKeyValues(options.NamespaceLabels)
+		if err != nil {
+			return fmt.Errorf("invalid --namespace-labels: %v", err)
+		}
+		namespaceAnnotations, err := parseKeyValues(options.NamespaceAnnotations)
+		if err != nil {
+			return fmt.Errorf("invalid --namespace-annotations: %v", err)
+		}
+
+		services, err := readServices(options.ServiceFilePath)
+		if err != nil {
+			return err
+		}
+		for _, service := range services {
+			if service.Namespace == "" {
+				service.Namespace = options.Namespace
+			}
+			namespace := &v1.Namespace{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:        service.Namespace,
+					Labels:      namespaceLabels,
+					Annotations: namespaceAnnotations,
+				},
+			}
+			serviceExplanations, err := explainService(service, namespace, conf, sources)
+			if err != nil {
+				return err
+			}
+			explanations = append(explanations, serviceExplanations...)
+		}
+	} else {
+		kubeClient, _, _, err := createClients(options.Kubeconfig, options.Context)
+		if err != nil {
+			return err
+		}
+
+		service, namespace, err := getServiceAndNamespace(kubeClient, options.Namespace, args[0])
+		if err != nil {
+			return err
+		}
+		serviceExplanations, err := explainService(service, namespace, conf, sources)
+		if err != nil {
+			return err
+		}
+		explanations = append(explanations, serviceExplanations...)
+	}
+
+	return printExplanations(cmd.OutOrStdout(), explanations, options.Output)
+}
+
+// explainService explains the settings of a service on every tier it is exposed on, see
+// config.Configuration.ServiceTiers
+func explainService(service *v1.Service, namespace *v1.Namespace, conf config.Configuration, sources config.Sources) ([]ServiceExplanation, error) {
+	tiers, err := conf.ServiceTiers(service)
+	if err != nil {
+		return nil, fmt.Errorf("can not explain Service %v/%v: %v", service.Namespace, service.Name, err)
+	}
+
+	explanations := []ServiceExplanation{}
+	for _, tier := range tiers {
+		explanations = append(explanations, ServiceExplanation{
+			Service:   service.Name,
+			Namespace: service.Namespace,
+			Tier:      tier,
+			Settings:  ingresses.Explain(service, namespace, conf, sources, tier),
+		})
+	}
+
+	return explanations, nil
+}
+
+// getServiceAndNamespace reads the given service and its namespace from the cluster. As reading namespaces needs a
+// ClusterRole, the namespace is nil if it can not be read
+func getServiceAndNamespace(clientset kubernetes.Interface, namespaceName string, serviceName string) (*v1.Service, *v1.Namespace, error) {
+	service, err := clientset.CoreV1().Services(namespaceName).Get(serviceName, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("can not get Service %v/%v: %v", namespaceName, serviceName, err)
+	}
+
+	namespace, err := clientset.CoreV1().Namespaces().Get(namespaceName, meta_v1.GetOptions{})
+	if err != nil {
+		logrus.Warnf("Can not get Namespace %v, its labels and annotations are not used: %v", namespaceName, err)
+		namespace = nil
+	}
+
+	return service, namespace, nil
+}
+
+func printExplanations(out io.Writer, explanations []ServiceExplanation, format string) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(explanations, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(data))
+	case "yaml":
+		data, err := yaml.Marshal(explanations)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(out, string(data))
+	default:
+		for i, explanation := range explanations {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintf(out, "Service %v/%v on tier %v\n", explanation.Namespace, explanation.Service, explanation.Tier)
+
+			writer := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(writer, "SETTING\tSOURCE\tTEMPLATE\tVALUE\tDETAIL")
+			for _, setting := range explanation.Settings {
+				value := setting.Value
+				if setting.Error != "" {
+					value = "<error: " + setting.Error + ">"
+				}
+				fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\n", setting.Name, setting.Source, setting.Template, value, setting.Detail)
+			}
+			writer.Flush()
+		}
+	}
+
+	return nil
+}
+
+// runningConfiguration holds the configuration the controller runs with, together with the source of every field.
+// It only changes when the controller accepts a reloaded configuration, so the explain endpoint never reports a file
+// which was rejected or not yet polled
+type runningConfiguration struct {
+	lock          sync.RWMutex
+	configuration config.Configuration
+	sources       config.Sources
+}
+
+func newRunningConfiguration(configuration config.Configuration, sources config.Sources) *runningConfiguration {
+	return &runningConfiguration{configuration: configuration, sources: sources}
+}
+
+func (r *runningConfiguration) get() (config.Configuration, config.Sources) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.configuration, r.sources
+}
+
+func (r *runningConfiguration) set(configuration config.Configuration, sources config.Sources) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.configuration = configuration
+	r.sources = sources
+}
+
+// newExplainHandler serves the explanation of the Service given by the namespace and service query parameters as
+// JSON, with the configuration the controller currently runs with. Only services of the watched namespaces are
+// explained, as the endpoint is not authenticated
+func newExplainHandler(clientset kubernetes.Interface, running func() (config.Configuration, config.Sources),
+	watched scope.Scope) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		namespaceName := r.URL.Query().Get("namespace")
+		serviceName := r.URL.Query().Get("service")
+		if namespaceName == "" || serviceName == "" {
+			http.Error(w, "the namespace and service query parameters are required", http.StatusBadRequest)
+			return
+		}
+
+		var watchedNamespace *v1.Namespace
+		if watched.NeedsNamespace() {
+			namespace, err := clientset.CoreV1().Namespaces().Get(namespaceName, meta_v1.GetOptions{})
+			if err == nil {
+				watchedNamespace = namespace
+			}
+		}
+		if !watched.Includes(namespaceName, watchedNamespace) {
+			http.Error(w, fmt.Sprintf("namespace %v is not watched", namespaceName), http.StatusForbidden)
+			return
+		}
+
+		service, namespace, err := getServiceAndNamespace(clientset, namespaceName, serviceName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		conf, sources := running()
+		explanations, err := explainService(service, namespace, conf, sources)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = printExplanations(w, explanations, "json")
+		if err != nil {
+			logrus.Errorf("Can not write explanation: %v", err)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/scope"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestExplainHandlerScope(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}}},
+		&v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "team-b"}},
+		&v1.Service{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "team-a"},
+			Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+		},
+		&v1.Service{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "team-b"},
+			Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+		},
+	)
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+	running := newRunningConfiguration(conf, config.Sources{})
+
+	tests := []struct {
+		name      string
+		names     []string
+		selector  string
+		namespace string
+		want      int
+	}{
+		{"watched namespace should be explained", []string{"team-a"}, "", "team-a", http.StatusOK},
+		{"namespace outside the watched names should be rejected", []string{"team-a"}, "", "team-b", http.StatusForbidden},
+		{"namespace matching the selector should be explained", nil, "team=a", "team-a", http.StatusOK},
+		{"namespace not matching the selector should be rejected", nil, "team=a", "team-b", http.StatusForbidden},
+		{"missing namespace should be rejected by the selector", nil, "team=a", "missing", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			watched, err := scope.New(test.names, test.selector)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest("GET", "/debug/explain?namespace="+test.namespace+"&service=app", nil)
+			newExplainHandler(clientset, running.get, watched).ServeHTTP(recorder, request)
+
+			if recorder.Code != test.want {
+				t.Errorf("Expected status %v, got %v: %v", test.want, recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestExplainHandlerRunningConfiguration(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "team-a"}},
+		&v1.Service{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "team-a"},
+			Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+		},
+	)
+	watched, err := scope.New([]string{"team-a"}, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+	running := newRunningConfiguration(conf, config.Sources{})
+	handler := newExplainHandler(clientset, running.get, watched)
+
+	tests := []struct {
+		name   string
+		domain string
+	}{
+		{"configuration at startup should be explained", ""},
+		{"accepted configuration should be explained", "reloaded.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want := conf.Domain
+			if test.domain != "" {
+				reloaded := conf
+				reloaded.Domain = test.domain
+				running.set(reloaded, config.Sources{"Domain": config.SourceFile})
+				want = test.domain
+			}
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest("GET", "/debug/explain?namespace=team-a&service=app", nil)
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("Expected status %v, got %v: %v", http.StatusOK, recorder.Code, recorder.Body.String())
+			}
+			if !strings.Contains(recorder.Body.String(), "app.team-a."+want) {
+				t.Errorf("Expected the explanation to use domain %v, got %v", want, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	routeClient "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/ownership"
+	"github.com/stakater/Xposer/internal/pkg/routes"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	StatusInSync          = "InSync"
+	StatusDrifted         = "Drifted"
+	StatusMissing         = "Missing"
+	StatusInvalidTemplate = "InvalidTemplate"
+	StatusConflict        = "Conflict"
+	StatusUnknown         = "Unknown"
+)
+
+// ListOptions holds the settings of the list command
+type ListOptions struct {
+	ConfigFilePath string
+	Namespace      string
+	Selector       string
+	Conflicts      bool
+	Kubeconfig     string
+	Context        string
+	Output         string
+}
+
+// Exposure describes the Ingress or Route generated for an exposed service on a tier, and whether it matches the
+// configuration
+type Exposure struct {
+	Namespace      string   `json:"namespace"`
+	Service        string   `json:"service"`
+	Tier           string   `json:"tier,omitempty"`
+	Kind           string   `json:"kind"`
+	Name           string   `json:"name,omitempty"`
+	Host           string   `json:"host,omitempty"`
+	Path           string   `json:"path,omitempty"`
+	TLSSecret      string   `json:"tlsSecret,omitempty"`
+	ConfigMapScope string   `json:"configMapScope,omitempty"`
+	Status         string   `json:"status"`
+	Drift          []string `json:"drift,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// NewListCommand creates the command which lists the exposed Services and the objects generated for them
+func NewListCommand() *cobra.Command {
+	options := &ListOptions{}
+
+	cmd := &cobra.Command{
+		Use:          "list",
+		Short:        "List exposed Services with their generated Ingress/Route, host, path, TLS secret, ConfigMap scope and drift status",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := bindEnvironment(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			// Keep the list readable, only problems are logged
+			logrus.SetLevel(logrus.WarnLevel)
+			return runList(cmd, options)
+		},
+	}
+	cmd.Flags().StringVar(&options.ConfigFilePath, "config", "configs/config.yaml", "Path of the configuration file used to detect drift")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "Namespace to list, all namespaces if empty")
+	cmd.Flags().StringVarP(&options.Selector, "selector", "l", "", "Label selector to filter the exposed Services, e.g. team=a")
+	cmd.Flags().BoolVar(&options.Conflicts, "conflicts", false, "Only list Services whose generated name is taken by an Ingress or Route not generated for them")
+	cmd.Flags().StringVar(&options.Kubeconfig, "kubeconfig", "", "Path of the kubeconfig file, the in-cluster config is used if empty")
+	cmd.Flags().StringVar(&options.Context, "context", "", "Kubeconfig context to use")
+	cmd.Flags().StringVarP(&options.Output, "output", "o", "table", "Output format, one of: table, json, yaml")
+	config.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func runList(cmd *cobra.Command, options *ListOptions) error {
+	if options.Output != "table" && options.Output != "json" && options.Output != "yaml" {
+		return fmt.Errorf("unknown output format %q, must be one of: table, json, yaml", options.Output)
+	}
+
+	conf, err := config.NewLoader(options.ConfigFilePath, cmd.Flags()).Load()
+	if err != nil {
+		return err
+	}
+
+	kubeClient, osClient, clusterType, err := createClients(options.Kubeconfig, options.Context)
+	if err != nil {
+		return err
+	}
+
+	var routesGetter routeClient.RoutesGetter
+	if osClient != nil {
+		routesGetter = osClient
+	}
+
+	exposures, err := listExposures(kubeClient, routesGetter, clusterType, conf, options.Namespace, options.Selector)
+	if err != nil {
+		return err
+	}
+	if options.Conflicts {
+		exposures = filterConflicts(exposures)
+	}
+
+	return printExposures(cmd.OutOrStdout(), exposures, options.Output)
+}
+
+// listExposures compares the objects generated for every exposed service matching the selector with the objects
+// the configuration would generate
+func listExposures(clientset kubernetes.Interface, routesGetter routeClient.RoutesGetter, clusterType string,
+	conf config.Configuration, namespace string, selector string) ([]Exposure, error) {
+	selection, err := config.NewSelection(conf)
+	if err != nil {
+		return nil, err
+	}
+	labelSelector := selection.ServiceSelector()
+	if selector != "" && labelSelector != "" {
+		labelSelector += "," + selector
+	} else if selector != "" {
+		labelSelector = selector
+	}
+
+	serviceList, err := clientset.CoreV1().Services(namespace).List(meta_v1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("can not list Services: %v", err)
+	}
+
+	namespaces := listNamespaces(clientset)
+
+	exposures := []Exposure{}
+	for i := range serviceList.Items {
+		service := &serviceList.Items[i]
+		if !selection.Exposes(service, namespaces[service.Namespace]) {
+			continue
+		}
+		exposure := Exposure{
+			Namespace: service.Namespace,
+			Service:   service.Name,
+			Kind:      "Ingress",
+		}
+		if clusterType == constants.OPENSHIFT {
+			exposure.Kind = "Route"
+		}
+
+		ingressInfos, err := ingresses.CreateIngressInfos(service, namespaces[service.Namespace], conf)
+		if err != nil {
+			exposure.Status = StatusInvalidTemplate
+			exposure.Error = err.Error()
+			exposures = append(exposures, exposure)
+			continue
+		}
+
+		// A service exposed on several tiers has one exposure per tier
+		for _, ingressInfo := range ingressInfos {
+			tierExposure := exposure
+			tierExposure.Tier = ingressInfo.Tier
+			tierExposure.ConfigMapScope = ingresses.GetExposeIngressURL(service)
+
+			if clusterType == constants.OPENSHIFT {
+				compareRoute(routesGetter, ingressInfo, &tierExposure)
+			} else {
+				compareIngress(clientset, ingressInfo, &tierExposure)
+			}
+			exposures = append(exposures, tierExposure)
+		}
+	}
+
+	return exposures, nil
+}
+
+// listNamespaces returns all namespaces by name. As listing namespaces needs a ClusterRole, the map is empty if they
+// can not be listed, and templates are rendered without namespace labels
+func listNamespaces(clientset kubernetes.Interface) map[string]*v1.Namespace {
+	namespaces := make(map[string]*v1.Namespace)
+
+	namespaceList, err := clientset.CoreV1().Namespaces().List(meta_v1.ListOptions{})
+	if err != nil {
+		logrus.Warnf("Can not list Namespaces, their labels and annotations are not used: %v", err)
+		return namespaces
+	}
+
+	for i := range namespaceList.Items {
+		namespaces[namespaceList.Items[i].Name] = &namespaceList.Items[i]
+	}
+
+	return namespaces
+}
+
+func compareIngress(clientset kubernetes.Interface, ingressInfo ingresses.IngressInfo, exposure *Exposure) {
+	desired := ingresses.CreateWithTLSFromIngressInfo(ingressInfo)
+	exposure.Name = desired.Name
+
+	actual, err := clientset.ExtensionsV1beta1().Ingresses(desired.Namespace).Get(desired.Name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		actual = desired
+		exposure.Status = StatusMissing
+	} else if err != nil {
+		actual = desired
+		exposure.Status = StatusUnknown
+		exposure.Error = err.Error()
+	} else if conflict := conflictingOwner(actual.ObjectMeta, ingressInfo); conflict != "" {
+		exposure.Status = StatusConflict
+		exposure.Error = conflict
+	} else {
+		exposure.Drift = ingresses.Diff(desired, actual)
+		exposure.Status = driftStatus(exposure.Drift)
+	}
+
+	// Shows what is in the cluster, or what would be created if the Ingress is missing
+	if len(actual.Spec.Rules) > 0 {
+		exposure.Host = actual.Spec.Rules[0].Host
+		if actual.Spec.Rules[0].HTTP != nil && len(actual.Spec.Rules[0].HTTP.Paths) > 0 {
+			exposure.Path = actual.Spec.Rules[0].HTTP.Paths[0].Path
+		}
+	}
+	if len(actual.Spec.TLS) > 0 {
+		exposure.TLSSecret = actual.Spec.TLS[0].SecretName
+	}
+}
+
+func compareRoute(routesGetter routeClient.RoutesGetter, ingressInfo ingresses.IngressInfo, exposure *Exposure) {
+	desired := routes.Create(ingressInfo.IngressName, ingressInfo.Namespace, ingressInfo.ForwardAnnotationsMap,
+		ingressInfo.IngressHost, ingressInfo.IngressPath, ingressInfo.ServiceName, ingressInfo.ServicePort)
+	exposure.Name = desired.Name
+	exposure.Host = desired.Spec.Host
+	exposure.Path = desired.Spec.Path
+
+	if routesGetter == nil {
+		exposure.Status = StatusUnknown
+		exposure.Error = "no OpenShift client"
+		return
+	}
+
+	actual, err := routesGetter.Routes(desired.Namespace).Get(desired.Name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		exposure.Status = StatusMissing
+	} else if err != nil {
+		exposure.Status = StatusUnknown
+		exposure.Error = err.Error()
+	} else {
+		if conflict := conflictingOwner(actual.ObjectMeta, ingressInfo); conflict != "" {
+			exposure.Status = StatusConflict
+			exposure.Error = conflict
+		} else {
+			exposure.Drift = routes.Diff(desired, actual)
+			exposure.Status = driftStatus(exposure.Drift)
+		}
+		exposure.Host = actual.Spec.Host
+		exposure.Path = actual.Spec.Path
+	}
+}
+
+// conflictingOwner describes who owns an object which has the generated name but was not generated for the service,
+// and is empty if the object was generated for it
+func conflictingOwner(objectMeta meta_v1.ObjectMeta, ingressInfo ingresses.IngressInfo) string {
+	ownerNamespace, ownerName, owned := ownership.Owner(objectMeta)
+	if !owned {
+		return "not generated by Xposer"
+	}
+	if ownerNamespace != ingressInfo.Namespace || ownerName != ingressInfo.ServiceName {
+		return "generated for service " + ownerNamespace + "/" + ownerName
+	}
+
+	return ""
+}
+
+func filterConflicts(exposures []Exposure) []Exposure {
+	conflicts := []Exposure{}
+	for _, exposure := range exposures {
+		if exposure.Status == StatusConflict {
+			conflicts = append(conflicts, exposure)
+		}
+	}
+
+	return conflicts
+}
+
+func driftStatus(drift []string) string {
+	if len(drift) > 0 {
+		return StatusDrifted
+	}
+
+	return StatusInSync
+}
+
+func printExposures(out io.Writer, exposures []Exposure, format string) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(exposures, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(data))
+	case "yaml":
+		data, err := yaml.Marshal(exposures)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(out, string(data))
+	default:
+		writer := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(writer, "NAMESPACE\tSERVICE\tTIER\tKIND\tNAME\tHOST\tPATH\tTLS SECRET\tCONFIGMAP\tSTATUS")
+		for _, exposure := range exposures {
+			status := exposure.Status
+			if len(exposure.Drift) > 0 {
+				status += " (" + strings.Join(exposure.Drift, ", ") + ")"
+			} else if exposure.Status == StatusConflict {
+				status += " (" + exposure.Error + ")"
+			}
+			fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", exposure.Namespace, exposure.Service, orNone(exposure.Tier), exposure.Kind,
+				orNone(exposure.Name), orNone(exposure.Host), orNone(exposure.Path), orNone(exposure.TLSSecret),
+				orNone(exposure.ConfigMapScope), status)
+		}
+		writer.Flush()
+	}
+
+	return nil
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+
+	return value
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestListExposures(t *testing.T) {
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+
+	inSync := createExposedService("in-sync", nil)
+	drifted := createExposedService("drifted", nil)
+	conflict := createExposedService("conflict", nil)
+	missing := createExposedService("missing", map[string]string{constants.FORWARD_ANNOTATION: "exposeIngressUrl: globally"})
+	invalid := createExposedService("invalid", map[string]string{"config.xposer.stakater.com/IngressNameTemplate": "{{.Labels.missing}}"})
+	notExposed := createExposedService("not-exposed", nil)
+	notExposed.Labels = nil
+
+	objects := []runtime.Object{inSync, drifted, conflict, missing, invalid, notExposed}
+	for _, service := range []*v1.Service{inSync, drifted, conflict} {
+		ingressInfo, err := ingresses.CreateIngressInfo(service, nil, conf)
+		if err != nil {
+			t.Fatalf("CreateIngressInfo() error = %v", err)
+		}
+		ingress := ingresses.CreateWithTLSFromIngressInfo(ingressInfo)
+		if service == drifted {
+			ingress.Spec.Rules[0].Host = "changed.example.com"
+		}
+		if service == conflict {
+			ingress.Labels = nil
+			ingress.Annotations = nil
+		}
+		objects = append(objects, ingress)
+	}
+
+	exposures, err := listExposures(fake.NewSimpleClientset(objects...), nil, constants.KUBERNETES, conf, "", "")
+	if err != nil {
+		t.Fatalf("listExposures() error = %v", err)
+	}
+
+	got := make(map[string]Exposure)
+	for _, exposure := range exposures {
+		got[exposure.Service] = exposure
+	}
+
+	tests := []struct {
+		name      string
+		service   string
+		want      string
+		wantHost  string
+		wantDrift []string
+		wantScope string
+	}{
+		{
+			name:     "matching ingress should be in sync",
+			service:  "in-sync",
+			want:     StatusInSync,
+			wantHost: "in-sync.team.example.com",
+		},
+		{
+			name:      "changed ingress should be drifted",
+			service:   "drifted",
+			want:      StatusDrifted,
+			wantHost:  "changed.example.com",
+			wantDrift: []string{"spec.rules"},
+		},
+		{
+			name:     "hand-made ingress with the generated name should be a conflict",
+			service:  "conflict",
+			want:     StatusConflict,
+			wantHost: "conflict.team.example.com",
+		},
+		{
+			name:      "missing ingress should be reported with its desired host",
+			service:   "missing",
+			want:      StatusMissing,
+			wantHost:  "missing.team.example.com",
+			wantScope: constants.GLOBALLY,
+		},
+		{
+			name:    "render error should be reported",
+			service: "invalid",
+			want:    StatusInvalidTemplate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exposure := got[tt.service]
+			if exposure.Status != tt.want {
+				t.Errorf("listExposures() status = %v, want %v", exposure.Status, tt.want)
+			}
+			if exposure.Host != tt.wantHost {
+				t.Errorf("listExposures() host = %v, want %v", exposure.Host, tt.wantHost)
+			}
+			if !reflect.DeepEqual(exposure.Drift, tt.wantDrift) {
+				t.Errorf("listExposures() drift = %v, want %v", exposure.Drift, tt.wantDrift)
+			}
+			if exposure.ConfigMapScope != tt.wantScope {
+				t.Errorf("listExposures() configmap scope = %v, want %v", exposure.ConfigMapScope, tt.wantScope)
+			}
+		})
+	}
+
+	if _, ok := got["not-exposed"]; ok {
+		t.Errorf("listExposures() should not list services which are not exposed")
+	}
+}
+
+func createExposedService(name string, annotations map[string]string) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        name,
+			Namespace:   "team",
+			Labels:      map[string]string{constants.EXPOSE: "true"},
+			Annotations: annotations,
+		},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+	}
+}
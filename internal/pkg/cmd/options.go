@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/dryrun"
+	"github.com/stakater/Xposer/internal/pkg/scope"
+	v1 "k8s.io/api/core/v1"
+)
+
+// XposerOptions holds the operational settings of the controller, the exposure settings are in config.Configuration
+type XposerOptions struct {
+	ConfigFilePath string
+	Namespace      string
+	Workers        int
+	ResyncPeriod   time.Duration
+	Kubeconfig     string
+	Context        string
+	MetricsAddress string
+	LogLevel       string
+	DryRun         string
+
+	// NamespaceSelector selects the watched namespaces by their labels, together with Namespace
+	NamespaceSelector string
+	// ExplainEndpoint serves /debug/explain on the metrics address, it is unauthenticated and off by default
+	ExplainEndpoint bool
+}
+
+// legacyEnvironmentVariables are read for flags which have no XPOSER_* environment variable set, to keep existing
+// deployments working
+var legacyEnvironmentVariables = map[string]string{
+	"config":     "CONFIG_FILE_PATH",
+	"namespace":  "KUBERNETES_NAMESPACE",
+	"kubeconfig": "KUBECONFIG",
+}
+
+// AddFlags registers the operational flags
+func (o *XposerOptions) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.ConfigFilePath, "config", "configs/config.yaml", "Path of the configuration file")
+	flags.StringVar(&o.Namespace, "namespace", v1.NamespaceAll, "Comma separated namespaces to watch for services, all namespaces if empty")
+	flags.StringVar(&o.NamespaceSelector, "watch-namespace-selector", "", "Label selector of the namespaces to watch for services, all namespaces if empty")
+	flags.IntVar(&o.Workers, "workers", 1, "Number of services processed in parallel")
+	flags.DurationVar(&o.ResyncPeriod, "resync-period", constants.RESYNC_PERIOD, "Period after which all services are processed again")
+	flags.StringVar(&o.Kubeconfig, "kubeconfig", "", "Path of the kubeconfig file, the in-cluster config is used if empty")
+	flags.StringVar(&o.Context, "context", "", "Kubeconfig context to use")
+	flags.StringVar(&o.MetricsAddress, "metrics-address", ":9090", "Address to serve metrics and the /debug/explain endpoint on, both are disabled if empty")
+	flags.BoolVar(&o.ExplainEndpoint, "explain-endpoint", false, "Serve the unauthenticated /debug/explain endpoint on the metrics address, which shows the labels, annotations and settings of watched services")
+	flags.StringVar(&o.LogLevel, "log-level", "info", "Log level, one of: debug, info, warning, error")
+	flags.StringVar(&o.DryRun, "dry-run", dryrun.ModeNone, "Log, record events and count changes instead of applying them, one of: none, client, server. server sends them as server side dry run to validate them")
+}
+
+// Validate checks the operational settings and applies the log level
+func (o *XposerOptions) Validate() error {
+	if o.Workers < 1 {
+		return fmt.Errorf("workers must be at least 1, got: %v", o.Workers)
+	}
+
+	if o.ResyncPeriod <= 0 {
+		return fmt.Errorf("resync-period must be positive, got: %v", o.ResyncPeriod)
+	}
+
+	_, err := o.Scope()
+	if err != nil {
+		return err
+	}
+
+	err = dryrun.ValidateMode(o.DryRun)
+	if err != nil {
+		return err
+	}
+
+	level, err := logrus.ParseLevel(o.LogLevel)
+	if err != nil {
+		return err
+	}
+	logrus.SetLevel(level)
+
+	return nil
+}
+
+// Scope returns the namespaces to watch
+func (o *XposerOptions) Scope() (scope.Scope, error) {
+	return scope.Parse(o.Namespace, o.NamespaceSelector)
+}
+
+// bindEnvironment sets every flag which was not given on the command line from its XPOSER_* environment variable,
+// so that flags take precedence over environment variables, which take precedence over the configuration file
+func bindEnvironment(flags *pflag.FlagSet) error {
+	var err error
+
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed {
+			return
+		}
+
+		value, found := os.LookupEnv(environmentVariableName(flag.Name))
+		if legacyName, hasLegacyName := legacyEnvironmentVariables[flag.Name]; !found && hasLegacyName {
+			value, found = os.LookupEnv(legacyName)
+		}
+
+		if found {
+			if setErr := flags.Set(flag.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid value %q for %v: %v", value, environmentVariableName(flag.Name), setErr)
+			}
+		}
+	})
+
+	return err
+}
+
+// environmentVariableName returns the environment variable for a flag, e.g. XPOSER_INGRESS_URL_TEMPLATE for
+// --ingress-url-template
+func environmentVariableName(flagName string) string {
+	return "XPOSER_" + strings.ToUpper(strings.Replace(flagName, "-", "_", -1))
+}
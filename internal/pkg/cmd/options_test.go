@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestBindEnvironment(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		environment map[string]string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name: "default should be used without flag and environment variable",
+			want: "configs/config.yaml",
+		},
+		{
+			name:        "environment variable should override default",
+			environment: map[string]string{"XPOSER_CONFIG": "/env/config.yaml"},
+			want:        "/env/config.yaml",
+		},
+		{
+			name:        "legacy environment variable should override default",
+			environment: map[string]string{"CONFIG_FILE_PATH": "/legacy/config.yaml"},
+			want:        "/legacy/config.yaml",
+		},
+		{
+			name:        "environment variable should override legacy environment variable",
+			environment: map[string]string{"XPOSER_CONFIG": "/env/config.yaml", "CONFIG_FILE_PATH": "/legacy/config.yaml"},
+			want:        "/env/config.yaml",
+		},
+		{
+			name:        "flag should override environment variable",
+			args:        []string{"--config=/flag/config.yaml"},
+			environment: map[string]string{"XPOSER_CONFIG": "/env/config.yaml"},
+			want:        "/flag/config.yaml",
+		},
+		{
+			name:        "invalid environment variable should return an error",
+			environment: map[string]string{"XPOSER_WORKERS": "many"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.environment {
+				os.Setenv(name, value)
+				defer os.Unsetenv(name)
+			}
+
+			options := &XposerOptions{}
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			options.AddFlags(flags)
+			err := flags.Parse(tt.args)
+			if err != nil {
+				t.Fatalf("Can not parse flags: %v", err)
+			}
+
+			err = bindEnvironment(flags)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("bindEnvironment() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && options.ConfigFilePath != tt.want {
+				t.Errorf("bindEnvironment() config = %v, want %v", options.ConfigFilePath, tt.want)
+			}
+		})
+	}
+}
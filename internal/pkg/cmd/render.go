@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/configmaps"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/routes"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// RenderOptions holds the settings of the render command
+type RenderOptions struct {
+	ServiceFilePath      string
+	ConfigFilePath       string
+	Namespace            string
+	NamespaceLabels      []string
+	NamespaceAnnotations []string
+	OpenShift            bool
+	Output               string
+}
+
+// NewRenderCommand creates the command which prints the objects Xposer would generate for a Service, without a cluster
+func NewRenderCommand() *cobra.Command {
+	options := &RenderOptions{}
+
+	cmd := &cobra.Command{
+		Use:          "render",
+		Short:        "Print the Ingress/Route and ConfigMap Xposer would generate for the Services in a file, without a cluster",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := bindEnvironment(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			// Keep the rendered objects readable, only problems are logged
+			logrus.SetLevel(logrus.WarnLevel)
+			return runRender(cmd, options)
+		},
+	}
+	cmd.Flags().StringVarP(&options.ServiceFilePath, "filename", "f", "", "File containing the Service(s) to render, - for stdin")
+	cmd.Flags().StringVar(&options.ConfigFilePath, "config", "configs/config.yaml", "Path of the configuration file")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "default", "Namespace of Services which do not set one")
+	cmd.Flags().StringArrayVar(&options.NamespaceLabels, "namespace-labels", nil, "Label of the Service namespace as key=value, may be repeated")
+	cmd.Flags().StringArrayVar(&options.NamespaceAnnotations, "namespace-annotations", nil, "Annotation of the Service namespace as key=value, which templates read as .NamespaceAnnotations, may be repeated")
+	cmd.Flags().BoolVar(&options.OpenShift, "openshift", false, "Render Routes instead of Ingresses")
+	cmd.Flags().StringVarP(&options.Output, "output", "o", "yaml", "Output format, one of: yaml, json")
+	config.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func runRender(cmd *cobra.Command, options *RenderOptions) error {
+	if options.ServiceFilePath == "" {
+		return fmt.Errorf("a Service file must be given with --filename")
+	}
+	if options.Output != "yaml" && options.Output != "json" {
+		return fmt.Errorf("unknown output format %q, must be one of: yaml, json", options.Output)
+	}
+
+	namespaceLabels, err := parseKeyValues(options.NamespaceLabels)
+	if err != nil {
+		return fmt.Errorf("invalid --namespace-labels: %v", err)
+	}
+	namespaceAnnotations, err := parseKeyValues(options.NamespaceAnnotations)
+	if err != nil {
+		return fmt.Errorf("invalid --namespace-annotations: %v", err)
+	}
+
+	conf, err := config.NewLoader(options.ConfigFilePath, cmd.Flags()).Load()
+	if err != nil {
+		return err
+	}
+
+	services, err := readServices(options.ServiceFilePath)
+	if err != nil {
+		return err
+	}
+
+	clusterType := constants.KUBERNETES
+	if options.OpenShift {
+		clusterType = constants.OPENSHIFT
+	}
+
+	selection, err := config.NewSelection(conf)
+	if err != nil {
+		return err
+	}
+
+	objects := []runtime.Object{}
+	for _, service := range services {
+		if service.Namespace == "" {
+			service.Namespace = options.Namespace
+		}
+
+		namespace := &v1.Namespace{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:        service.Namespace,
+				Labels:      namespaceLabels,
+				Annotations: namespaceAnnotations,
+			},
+		}
+		if !selection.Exposes(service, namespace) {
+			fmt.Fprintf(cmd.OutOrStderr(), "Service %v/%v is not %v in a selected namespace, it would not be exposed yet\n",
+				service.Namespace, service.Name, selection.Describe())
+		}
+		rendered, err := renderService(service, namespace, conf, clusterType)
+		if err != nil {
+			return fmt.Errorf("can not render Service %v/%v: %v", service.Namespace, service.Name, err)
+		}
+		objects = append(objects, rendered...)
+	}
+
+	return printObjects(cmd.OutOrStdout(), objects, options.Output)
+}
+
+// renderService runs the same generation as the controller, and returns the objects it would create for the service
+// on all its tiers
+func renderService(service *v1.Service, namespace *v1.Namespace, conf config.Configuration, clusterType string) ([]runtime.Object, error) {
+	ingressInfos, err := ingresses.CreateIngressInfos(service, namespace, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []runtime.Object{}
+	urls := make(map[string]string)
+	for _, ingressInfo := range ingressInfos {
+		if clusterType == constants.OPENSHIFT {
+			route := routes.Create(ingressInfo.IngressName, ingressInfo.Namespace, ingressInfo.ForwardAnnotationsMap,
+				ingressInfo.IngressHost, ingressInfo.IngressPath, ingressInfo.ServiceName, ingressInfo.ServicePort)
+			route.TypeMeta = meta_v1.TypeMeta{Kind: "Route", APIVersion: "route.openshift.io/v1"}
+			objects = append(objects, route)
+			continue
+		}
+
+		ingress := ingresses.CreateWithTLSFromIngressInfo(ingressInfo)
+		ingress.TypeMeta = meta_v1.TypeMeta{Kind: "Ingress", APIVersion: "extensions/v1beta1"}
+		objects = append(objects, ingress)
+		urls[configmaps.ConfigMapTierKey(service, ingressInfo.Tier)] = ingressInfo.IngressHost
+	}
+
+	// Globally exposed URLs are published to the xposer configmap of every namespace, the one of the service namespace is shown
+	exposeIngressURL := ingresses.GetExposeIngressURL(service)
+	if len(urls) > 0 && (exposeIngressURL == constants.GLOBALLY || exposeIngressURL == constants.LOCALLY) {
+		configMap := configmaps.CreateConfigMapObject(service.Namespace, urls)
+		configMap.TypeMeta = meta_v1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"}
+		objects = append(objects, configMap)
+	}
+
+	return objects, nil
+}
+
+// readServices decodes every Service of the given YAML or JSON file, which may contain several documents
+func readServices(path string) ([]*v1.Service, error) {
+	var reader io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("can not read Service file: %v", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	services := []*v1.Service{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(reader, 4096)
+	for {
+		service := &v1.Service{}
+		err := decoder.Decode(service)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("can not decode Service file %v: %v", path, err)
+		}
+		if service.Kind == "" && service.Name == "" {
+			// Empty document
+			continue
+		}
+		if service.Kind != "" && service.Kind != "Service" {
+			return nil, fmt.Errorf("%v contains a %v, only Services can be rendered", path, service.Kind)
+		}
+		services = append(services, service)
+	}
+
+	if len(services) == 0 {
+		return nil, fmt.Errorf("%v does not contain any Service", path)
+	}
+
+	return services, nil
+}
+
+func printObjects(out io.Writer, objects []runtime.Object, format string) error {
+	if format == "json" {
+		list := &v1.List{TypeMeta: meta_v1.TypeMeta{Kind: "List", APIVersion: "v1"}}
+		for _, object := range objects {
+			raw, err := json.Marshal(object)
+			if err != nil {
+				return err
+			}
+			list.Items = append(list.Items, runtime.RawExtension{Raw: raw})
+		}
+		data, err := json.MarshalIndent(list, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(data))
+		return nil
+	}
+
+	for i, object := range objects {
+		data, err := yaml.Marshal(object)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Fprintln(out, "---")
+		}
+		fmt.Fprint(out, string(data))
+	}
+
+	return nil
+}
+
+// parseKeyValues parses key=value pairs into a map
+func parseKeyValues(pairs []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, pair := range pairs {
+		keyValue := strings.SplitN(pair, "=", 2)
+		if len(keyValue) != 2 || keyValue[0] == "" {
+			return nil, fmt.Errorf("%q is not in key=value format", pair)
+		}
+		values[keyValue[0]] = keyValue[1]
+	}
+
+	return values, nil
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	osV1 "github.com/openshift/api/route/v1"
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderService(t *testing.T) {
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		namespace   map[string]string
+		clusterType string
+		wantKinds   []string
+		wantHost    string
+		wantTLS     bool
+	}{
+		{
+			name:        "ingress should be rendered with the default config",
+			clusterType: constants.KUBERNETES,
+			wantKinds:   []string{"Ingress"},
+			wantHost:    "app.team.example.com",
+		},
+		{
+			name:        "config annotations of the namespace should be ignored",
+			namespace:   map[string]string{"config.xposer.stakater.com/Domain": "namespace.com", "config.xposer.stakater.com/TLS": "true"},
+			clusterType: constants.KUBERNETES,
+			wantKinds:   []string{"Ingress"},
+			wantHost:    "app.team.example.com",
+		},
+		{
+			name:        "namespace annotations should be available to templates",
+			annotations: map[string]string{"config.xposer.stakater.com/IngressURLTemplate": "{{.Service}}.{{.NamespaceAnnotations.owner}}.{{.Domain}}"},
+			namespace:   map[string]string{"owner": "web", "config.xposer.stakater.com/Domain": "namespace.com"},
+			clusterType: constants.KUBERNETES,
+			wantKinds:   []string{"Ingress"},
+			wantHost:    "app.web.example.com",
+		},
+		{
+			name:        "service annotations should override the default config",
+			annotations: map[string]string{"config.xposer.stakater.com/Domain": "service.com"},
+			clusterType: constants.KUBERNETES,
+			wantKinds:   []string{"Ingress"},
+			wantHost:    "app.team.service.com",
+		},
+		{
+			name:        "configmap should be rendered when the url is exposed",
+			annotations: map[string]string{constants.FORWARD_ANNOTATION: "exposeIngressUrl: locally"},
+			clusterType: constants.KUBERNETES,
+			wantKinds:   []string{"Ingress", "ConfigMap"},
+			wantHost:    "app.team.example.com",
+		},
+		{
+			name:        "route should be rendered on openshift",
+			clusterType: constants.OPENSHIFT,
+			wantKinds:   []string{"Route"},
+			wantHost:    "app.team.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &v1.Service{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:        "app",
+					Namespace:   "team",
+					Labels:      map[string]string{constants.EXPOSE: "true"},
+					Annotations: tt.annotations,
+				},
+				Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+			}
+			namespace := &v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "team", Annotations: tt.namespace}}
+
+			objects, err := renderService(service, namespace, conf, tt.clusterType)
+			if err != nil {
+				t.Fatalf("renderService() error = %v", err)
+			}
+
+			kinds := []string{}
+			for _, object := range objects {
+				kinds = append(kinds, object.GetObjectKind().GroupVersionKind().Kind)
+			}
+			if !reflect.DeepEqual(kinds, tt.wantKinds) {
+				t.Fatalf("renderService() kinds = %v, want %v", kinds, tt.wantKinds)
+			}
+
+			switch object := objects[0].(type) {
+			case *v1beta1.Ingress:
+				if host := object.Spec.Rules[0].Host; host != tt.wantHost {
+					t.Errorf("renderService() host = %v, want %v", host, tt.wantHost)
+				}
+				if (len(object.Spec.TLS) > 0) != tt.wantTLS {
+					t.Errorf("renderService() TLS = %v, want %v", object.Spec.TLS, tt.wantTLS)
+				}
+			case *osV1.Route:
+				if object.Spec.Host != tt.wantHost {
+					t.Errorf("renderService() host = %v, want %v", object.Spec.Host, tt.wantHost)
+				}
+			}
+		})
+	}
+}
+
+func TestParseKeyValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		pairs   []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "pairs should be parsed",
+			pairs: []string{"env=prod", "url=a=b", "empty="},
+			want:  map[string]string{"env": "prod", "url": "a=b", "empty": ""},
+		},
+		{
+			name:    "pair without value should be rejected",
+			pairs:   []string{"env"},
+			wantErr: true,
+		},
+		{
+			name:    "pair without key should be rejected",
+			pairs:   []string{"=prod"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseKeyValues(tt.pairs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseKeyValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseKeyValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
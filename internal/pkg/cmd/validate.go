@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"github.com/stakater/Xposer/internal/pkg/config"
+)
+
+// NewValidateCommand creates the command which validates a configuration file without starting the controller
+func NewValidateCommand() *cobra.Command {
+	var configFilePath string
+
+	cmd := &cobra.Command{
+		Use:          "validate",
+		Short:        "Validate an Xposer configuration file, together with the configuration flags and XPOSER_* environment variables",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := bindEnvironment(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			return validateConfig(cmd, config.NewLoader(configFilePath, cmd.Flags()))
+		},
+	}
+	cmd.Flags().StringVar(&configFilePath, "config", "configs/config.yaml", "Path of the configuration file to validate")
+	config.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func validateConfig(cmd *cobra.Command, loader *config.Loader) error {
+	source, err := ioutil.ReadFile(loader.FilePath())
+	if err != nil {
+		return fmt.Errorf("can not read configuration file: %v", err)
+	}
+
+	_, allErrs, err := loader.ValidateSource(source)
+	if err != nil {
+		return fmt.Errorf("can not parse configuration file %v: %v", loader.FilePath(), err)
+	}
+
+	out := cmd.OutOrStdout()
+	if len(allErrs) == 0 {
+		fmt.Fprintf(out, "%v is valid\n", loader.FilePath())
+		return nil
+	}
+
+	fmt.Fprintf(out, "%v is invalid:\n", loader.FilePath())
+	for _, validationErr := range allErrs {
+		fmt.Fprintf(out, "  - %v\n", validationErr)
+	}
+
+	return fmt.Errorf("%v has %d error(s)", loader.FilePath(), len(allErrs))
+}
@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"os"
+	"net/http"
 
 	routeClient "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
 	"github.com/sirupsen/logrus"
@@ -9,40 +9,91 @@ import (
 	"github.com/stakater/Xposer/internal/pkg/config"
 	"github.com/stakater/Xposer/internal/pkg/constants"
 	"github.com/stakater/Xposer/internal/pkg/controller"
+	"github.com/stakater/Xposer/internal/pkg/dryrun"
+	"github.com/stakater/Xposer/internal/pkg/metrics"
 	"github.com/stakater/Xposer/pkg/kube"
-	"k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 )
 
 func NewXposerCommand() *cobra.Command {
+	options := &XposerOptions{}
+
 	cmds := &cobra.Command{
 		Use:   "xposer",
 		Short: "A Kubernetes controller to watch Services and generate Ingresses/Routes and TLS Certificates automatically",
-		Run:   startXposer,
+		Run: func(cmd *cobra.Command, args []string) {
+			startXposer(cmd, options)
+		},
 	}
+	options.AddFlags(cmds.Flags())
+	config.AddFlags(cmds.Flags())
+
+	cmds.AddCommand(NewValidateCommand())
+	cmds.AddCommand(NewRenderCommand())
+	cmds.AddCommand(NewExplainCommand())
+	cmds.AddCommand(NewListCommand())
+	cmds.AddCommand(NewCleanupCommand())
+	cmds.AddCommand(NewDoctorCommand())
 	return cmds
 }
 
-func startXposer(cmd *cobra.Command, args []string) {
-	currentNamespace := os.Getenv("KUBERNETES_NAMESPACE")
-	if currentNamespace == "" {
-		currentNamespace = v1.NamespaceAll
-		logrus.Infof("KUBERNETES_NAMESPACE is unset, will monitor services in all namespaces.")
+func startXposer(cmd *cobra.Command, options *XposerOptions) {
+	err := bindEnvironment(cmd.Flags())
+	if err != nil {
+		logrus.Fatalf("Can not read settings from environment: %v", err)
+	}
+
+	err = options.Validate()
+	if err != nil {
+		logrus.Fatalf("Invalid settings: %v", err)
+	}
+
+	watched, err := options.Scope()
+	if err != nil {
+		logrus.Fatalf("Invalid settings: %v", err)
+	}
+	currentNamespace := watched.Namespace()
+	if watched.All() {
+		logrus.Infof("No namespace is set, will monitor services in all namespaces.")
+	} else if currentNamespace == constants.ALL_NAMESPACES {
+		logrus.Infof("Will monitor services in %v, the services of all namespaces are watched and filtered", watched)
 	}
 
-	var kubeClient kubernetes.Interface
 	var osClient *routeClient.RouteV1Client
 
-	cfg, err := rest.InClusterConfig()
+	cfg, err := kube.GetConfig(options.Kubeconfig, options.Context)
 	if err != nil {
-		kubeClient = kube.GetClientOutOfCluster()
-	} else {
-		kubeClient = kube.GetClient()
+		logrus.Fatalf("Can not get kubernetes config: %v", err)
+	}
+
+	// In dry run mode every write of the clients is intercepted, while reads still reach the cluster
+	var interceptor *dryrun.Interceptor
+	if options.DryRun != dryrun.ModeNone {
+		interceptor = dryrun.NewInterceptor(options.DryRun)
+		wrapTransport := cfg.WrapTransport
+		cfg.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+			if wrapTransport != nil {
+				rt = wrapTransport(rt)
+			}
+			return interceptor.Wrap(rt)
+		}
+		logrus.Infof("Running in %v dry run mode, no changes are applied", options.DryRun)
+	}
+
+	kubeClient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		logrus.Fatalf("Can not create kubernetes client: %v", err)
+	}
+
+	if options.DryRun == dryrun.ModeServer {
+		err = dryrun.SupportsServerSide(kubeClient.Discovery())
+		if err != nil {
+			logrus.Fatalf("Can not run in server dry run mode: %v", err)
+		}
 	}
 
 	var clusterType = constants.KUBERNETES
-	if kube.IsOpenShift(kubeClient.(*kubernetes.Clientset)) {
+	if kube.IsOpenShift(kubeClient) {
 		clusterType = constants.OPENSHIFT
 		osClient, err = routeClient.NewForConfig(cfg)
 		if err != nil {
@@ -50,16 +101,47 @@ func startXposer(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	config := config.GetControllerConfig()
-	controller := controller.NewController(kubeClient, osClient, config, clusterType, currentNamespace)
+	configLoader := config.NewLoader(options.ConfigFilePath, cmd.Flags())
+	controllerConfig, sources, err := configLoader.Explain()
+	if err != nil {
+		logrus.Fatalf("Can not start Xposer without a valid configuration: %v", err)
+	}
+
+	// Missing permissions otherwise only show up as failing requests, e.g. when publishing URLs globally
+	checkPermissions(kubeClient, controllerConfig, watched, clusterType)
+
+	controller := controller.NewController(kubeClient, osClient, controllerConfig, clusterType, watched, options.ResyncPeriod)
+	running := newRunningConfiguration(controllerConfig, sources)
+	if interceptor != nil {
+		interceptor.OnChange(controller.RecordDryRunChange)
+	}
 
 	if currentNamespace != "" {
 		logrus.Infof("Controller started in the namespace: %v, with cluster type: %v", currentNamespace, clusterType)
 	}
 
+	if options.MetricsAddress != "" {
+		debugHandlers := map[string]http.Handler{}
+		if options.ExplainEndpoint {
+			debugHandlers["/debug/explain"] = newExplainHandler(kubeClient, running.get, watched)
+		}
+		go metrics.Serve(options.MetricsAddress, debugHandlers)
+	}
+
 	stop := make(chan struct{})
 	defer close(stop)
-	go controller.Run(1, stop)
+	go controller.Run(options.Workers, stop)
+
+	// Reload the configuration whenever its file changes, the explain endpoint only follows accepted configurations
+	watcher := config.NewWatcher(configLoader, constants.CONFIG_POLL_PERIOD,
+		func(conf config.Configuration, sources config.Sources) error {
+			err := controller.UpdateConfig(conf)
+			if err == nil {
+				running.set(conf, sources)
+			}
+			return err
+		})
+	go watcher.Run(stop)
 
 	// Wait forever
 	select {}
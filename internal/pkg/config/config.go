@@ -2,7 +2,6 @@ package config
 
 import (
 	"io/ioutil"
-	"os"
 
 	"github.com/sirupsen/logrus"
 
@@ -16,9 +15,53 @@ type Configuration struct {
 	IngressNameTemplate   string `yaml:"ingressNameTemplate"`
 	TLS                   bool   `yaml:"tls"`
 	TLSSecretNameTemplate string `yaml:"tlsSecretNameTemplate"`
+	ClusterName           string `yaml:"clusterName"`
+	DriftPolicy           string `yaml:"driftPolicy"`
+	AdoptionPolicy        string `yaml:"adoptionPolicy"`
+	HostClaimScope        string `yaml:"hostClaimScope"`
+	// Normalize* make the rendered values valid DNS-1123 names and hosts, see templates.NormalizeName
+	NormalizeIngressName   bool `yaml:"normalizeIngressName"`
+	NormalizeHost          bool `yaml:"normalizeHost"`
+	NormalizeTLSSecretName bool `yaml:"normalizeTLSSecretName"`
+	// ExposeSelector and ExposeAnnotation select the services to expose, NamespaceSelector and
+	// ExcludeNamespaceSelector the namespaces they may be exposed in, see Selection
+	ExposeSelector           string `yaml:"exposeSelector"`
+	ExposeAnnotation         string `yaml:"exposeAnnotation"`
+	NamespaceSelector        string `yaml:"namespaceSelector"`
+	ExcludeNamespaceSelector string `yaml:"excludeNamespaceSelector"`
+	// IngressClass is set as the kubernetes.io/ingress.class annotation of generated Ingresses, if not empty
+	IngressClass string `yaml:"ingressClass"`
+	// Tiers are named exposures with their own settings, a service gets one Ingress or Route per tier it is exposed on
+	Tiers map[string]Tier `yaml:"tiers"`
+	// TierLabel is the label whose value names the tier of a service, see TierLabelKey
+	TierLabel string `yaml:"tierLabel"`
+	// DefaultAnnotations are forwarded to the objects generated for every service, AnnotationProfiles are named sets
+	// of annotations which services pick with the annotation-profiles annotation
+	DefaultAnnotations map[string]string            `yaml:"defaultAnnotations"`
+	AnnotationProfiles map[string]map[string]string `yaml:"annotationProfiles"`
 }
 
-//ReadConfig function that reads the yaml file
+// Policies applied when a generated Ingress or Route is changed or deleted by someone else
+const (
+	DriftPolicyRevert = "revert"
+	DriftPolicyReport = "report"
+	DriftPolicyIgnore = "ignore"
+)
+
+// Policies applied when an Ingress or Route with the generated name exists, which was not generated for the service
+const (
+	AdoptionPolicyRefuse    = "refuse"
+	AdoptionPolicyAdopt     = "adopt"
+	AdoptionPolicyOverwrite = "overwrite"
+)
+
+// Scopes of the objects whose hosts and paths are checked for collisions with the generated ones
+const (
+	HostClaimScopeManaged = "managed"
+	HostClaimScopeAll     = "all"
+)
+
+// ReadConfig function that reads the yaml file
 func ReadConfig(filePath string) (Configuration, error) {
 	var config Configuration
 	// Read YML
@@ -28,8 +71,15 @@ func ReadConfig(filePath string) (Configuration, error) {
 		return config, err
 	}
 
+	return parseConfig(source)
+}
+
+// parseConfig unmarshalls the given yaml document into a Configuration
+func parseConfig(source []byte) (Configuration, error) {
+	var config Configuration
+
 	// Unmarshall
-	err = yaml.Unmarshal(source, &config)
+	err := yaml.Unmarshal(source, &config)
 	if err != nil {
 		logrus.Errorf("Error unmarshalling config: %v", err)
 		return config, err
@@ -38,7 +88,7 @@ func ReadConfig(filePath string) (Configuration, error) {
 	return config, nil
 }
 
-//WriteConfig function that can write to the yaml file
+// WriteConfig function that can write to the yaml file
 func WriteConfig(config Configuration, path string) error {
 	b, err := yaml.Marshal(config)
 	if err != nil {
@@ -52,16 +102,3 @@ func WriteConfig(config Configuration, path string) error {
 
 	return nil
 }
-
-func GetControllerConfig() Configuration {
-	configFilePath := os.Getenv("CONFIG_FILE_PATH")
-	if len(configFilePath) == 0 {
-		configFilePath = "configs/config.yaml"
-	}
-
-	configuration, err := ReadConfig(configFilePath)
-	if err != nil {
-		logrus.Errorf("Can not read configuration file with the following error: %v", err)
-	}
-	return configuration
-}
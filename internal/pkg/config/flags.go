@@ -0,0 +1,207 @@
+package config
+
+import (
+	"github.com/spf13/pflag"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+)
+
+// configurationFlag binds a command line flag to a configuration field
+type configurationFlag struct {
+	name   string
+	field  string
+	usage  string
+	isBool bool
+	apply  func(configuration *Configuration, flags *pflag.FlagSet)
+}
+
+var configurationFlags = []configurationFlag{
+	{
+		name:  "domain",
+		field: "Domain",
+		usage: "Domain used to render ingress hosts",
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.Domain, _ = flags.GetString("domain")
+		},
+	},
+	{
+		name:  "ingress-url-template",
+		field: "IngressURLTemplate",
+		usage: "Template of the ingress host, anything after the first / is used as path",
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.IngressURLTemplate, _ = flags.GetString("ingress-url-template")
+		},
+	},
+	{
+		name:  "ingress-url-path",
+		field: "IngressURLPath",
+		usage: "Template of the ingress path",
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.IngressURLPath, _ = flags.GetString("ingress-url-path")
+		},
+	},
+	{
+		name:  "ingress-name-template",
+		field: "IngressNameTemplate",
+		usage: "Template of the ingress name",
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.IngressNameTemplate, _ = flags.GetString("ingress-name-template")
+		},
+	},
+	{
+		name:   "tls",
+		field:  "TLS",
+		usage:  "Add TLS to generated ingresses",
+		isBool: true,
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.TLS, _ = flags.GetBool("tls")
+		},
+	},
+	{
+		name:  "tls-secret-name-template",
+		field: "TLSSecretNameTemplate",
+		usage: "Template of the TLS secret name, NO_SECRET lets certmanager derive it from the ingress name",
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.TLSSecretNameTemplate, _ = flags.GetString("tls-secret-name-template")
+		},
+	},
+	{
+		name:  "cluster-name",
+		field: "ClusterName",
+		usage: "Name of the cluster, available as {{.ClusterName}} in templates",
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.ClusterName, _ = flags.GetString("cluster-name")
+		},
+	},
+	{
+		name:  "drift-policy",
+		field: "DriftPolicy",
+		usage: "What to do when a generated Ingress or Route is changed or deleted, one of: revert, report, ignore",
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.DriftPolicy, _ = flags.GetString("drift-policy")
+		},
+	},
+	{
+		name:  "adoption-policy",
+		field: "AdoptionPolicy",
+		usage: "What to do when an Ingress or Route with the generated name already exists, one of: refuse, adopt, overwrite",
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.AdoptionPolicy, _ = flags.GetString("adoption-policy")
+		},
+	},
+	{
+		name:  "host-claim-scope",
+		field: "HostClaimScope",
+		usage: "Objects whose hosts and paths can not be claimed again, one of: managed (generated by Xposer), all",
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.HostClaimScope, _ = flags.GetString("host-claim-scope")
+		},
+	},
+	{
+		name:   "normalize-ingress-name",
+		field:  "NormalizeIngressName",
+		usage:  "Lower case, replace invalid characters and truncate rendered Ingress names with a hash, true by default",
+		isBool: true,
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.NormalizeIngressName, _ = flags.GetBool("normalize-ingress-name")
+		},
+	},
+	{
+		name:   "normalize-host",
+		field:  "NormalizeHost",
+		usage:  "Lower case, replace invalid characters and truncate every label of rendered hosts with a hash, true by default",
+		isBool: true,
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.NormalizeHost, _ = flags.GetBool("normalize-host")
+		},
+	},
+	{
+		name:   "normalize-tls-secret-name",
+		field:  "NormalizeTLSSecretName",
+		usage:  "Lower case, replace invalid characters and truncate rendered TLS secret names with a hash, true by default",
+		isBool: true,
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.NormalizeTLSSecretName, _ = flags.GetBool("normalize-tls-secret-name")
+		},
+	},
+	{
+		name:  "ingress-class",
+		field: "IngressClass",
+		usage: "Ingress class of generated Ingresses, set as the kubernetes.io/ingress.class annotation",
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.IngressClass, _ = flags.GetString("ingress-class")
+		},
+	},
+	{
+		name:  "expose-selector",
+		field: "ExposeSelector",
+		usage: "Label selector of the services to expose, expose=true by default",
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.ExposeSelector, _ = flags.GetString("expose-selector")
+		},
+	},
+	{
+		name:  "expose-annotation",
+		field: "ExposeAnnotation",
+		usage: "Annotation which exposes a service when set to true, in addition to the expose selector",
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.ExposeAnnotation, _ = flags.GetString("expose-annotation")
+		},
+	},
+	{
+		name:  "namespace-selector",
+		field: "NamespaceSelector",
+		usage: "Label selector of the namespaces whose services may be exposed, all namespaces if empty",
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.NamespaceSelector, _ = flags.GetString("namespace-selector")
+		},
+	},
+	{
+		name:  "exclude-namespace-selector",
+		field: "ExcludeNamespaceSelector",
+		usage: "Label selector of the namespaces whose services are never exposed",
+		apply: func(configuration *Configuration, flags *pflag.FlagSet) {
+			configuration.ExcludeNamespaceSelector, _ = flags.GetString("exclude-namespace-selector")
+		},
+	},
+}
+
+// DefaultConfiguration returns the configuration used for every field which is not set anywhere else
+func DefaultConfiguration() Configuration {
+	return Configuration{
+		IngressURLTemplate:     "{{.Service}}.{{.Namespace}}.{{.Domain}}",
+		IngressURLPath:         "/",
+		IngressNameTemplate:    "{{.Service}}",
+		DriftPolicy:            DriftPolicyRevert,
+		AdoptionPolicy:         AdoptionPolicyAdopt,
+		HostClaimScope:         HostClaimScopeManaged,
+		NormalizeIngressName:   true,
+		NormalizeHost:          true,
+		NormalizeTLSSecretName: true,
+		ExposeSelector:         constants.EXPOSE + "=true",
+	}
+}
+
+// AddFlags registers a flag for every configuration field. The defaults of the flags are empty, as a flag only
+// overrides the configuration file when it is set
+func AddFlags(flags *pflag.FlagSet) {
+	for _, configurationFlag := range configurationFlags {
+		if configurationFlag.isBool {
+			flags.Bool(configurationFlag.name, false, configurationFlag.usage)
+		} else {
+			flags.String(configurationFlag.name, "", configurationFlag.usage)
+		}
+	}
+}
+
+// ApplyFlags overrides the configuration fields whose flags have been set
+func ApplyFlags(configuration *Configuration, flags *pflag.FlagSet) {
+	if flags == nil {
+		return
+	}
+
+	for _, configurationFlag := range configurationFlags {
+		if flags.Changed(configurationFlag.name) {
+			configurationFlag.apply(configuration, flags)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"io/ioutil"
+	"reflect"
+
+	"github.com/spf13/pflag"
+	yaml "gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Loader builds the effective configuration from the defaults, the configuration file and the flags, in
+// increasing order of precedence
+type Loader struct {
+	filePath string
+	flags    *pflag.FlagSet
+}
+
+// NewLoader creates a Loader for the given file, flags can be nil if there are no overrides
+func NewLoader(filePath string, flags *pflag.FlagSet) *Loader {
+	return &Loader{
+		filePath: filePath,
+		flags:    flags,
+	}
+}
+
+// FilePath returns the path of the configuration file
+func (l *Loader) FilePath() string {
+	return l.filePath
+}
+
+// Load reads the configuration file and returns the effective configuration, which must be valid
+func (l *Loader) Load() (Configuration, error) {
+	source, err := ioutil.ReadFile(l.filePath)
+	if err != nil {
+		return Configuration{}, err
+	}
+
+	return l.LoadSource(source)
+}
+
+// LoadSource returns the effective configuration for the given document, which must be valid
+func (l *Loader) LoadSource(source []byte) (Configuration, error) {
+	configuration, allErrs, err := l.ValidateSource(source)
+	if err != nil {
+		return configuration, err
+	}
+
+	return configuration, allErrs.ToAggregate()
+}
+
+// ValidateSource builds the effective configuration for the given document, and returns every unknown key and
+// invalid field found in it. An error is only returned if the document can not be parsed at all
+func (l *Loader) ValidateSource(source []byte) (Configuration, field.ErrorList, error) {
+	configuration := DefaultConfiguration()
+	err := yaml.Unmarshal(source, &configuration)
+	if err != nil {
+		return configuration, nil, err
+	}
+
+	var document map[string]interface{}
+	err = yaml.Unmarshal(source, &document)
+	if err != nil {
+		return configuration, nil, err
+	}
+
+	allErrs := validateKnownKeys(document, reflect.TypeOf(configuration), nil)
+
+	ApplyFlags(&configuration, l.flags)
+	allErrs = append(allErrs, ValidateConfiguration(configuration)...)
+
+	return configuration, allErrs, nil
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestLoaderLoadSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		args    []string
+		want    Configuration
+		wantErr bool
+	}{
+		{
+			name:   "defaults should be used for fields missing in the file",
+			source: "domain: stakater.com\n",
+			want: Configuration{
+				Domain:                 "stakater.com",
+				IngressURLTemplate:     "{{.Service}}.{{.Namespace}}.{{.Domain}}",
+				IngressURLPath:         "/",
+				IngressNameTemplate:    "{{.Service}}",
+				DriftPolicy:            DriftPolicyRevert,
+				AdoptionPolicy:         AdoptionPolicyAdopt,
+				HostClaimScope:         HostClaimScopeManaged,
+				NormalizeIngressName:   true,
+				NormalizeHost:          true,
+				NormalizeTLSSecretName: true,
+				ExposeSelector:         "expose=true",
+			},
+		},
+		{
+			name:   "flags should override the file",
+			source: "domain: stakater.com\ntls: true\n",
+			args:   []string{"--domain=example.com", "--tls=false", "--ingress-name-template={{.Service}}-{{.Namespace}}"},
+			want: Configuration{
+				Domain:                 "example.com",
+				IngressURLTemplate:     "{{.Service}}.{{.Namespace}}.{{.Domain}}",
+				IngressURLPath:         "/",
+				IngressNameTemplate:    "{{.Service}}-{{.Namespace}}",
+				DriftPolicy:            DriftPolicyRevert,
+				AdoptionPolicy:         AdoptionPolicyAdopt,
+				HostClaimScope:         HostClaimScopeManaged,
+				NormalizeIngressName:   true,
+				NormalizeHost:          true,
+				NormalizeTLSSecretName: true,
+				ExposeSelector:         "expose=true",
+			},
+		},
+		{
+			name:    "flags should be validated",
+			source:  "domain: stakater.com\n",
+			args:    []string{"--domain=Not_A_Domain"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			AddFlags(flags)
+			err := flags.Parse(tt.args)
+			if err != nil {
+				t.Fatalf("Can not parse flags: %v", err)
+			}
+
+			got, err := NewLoader("", flags).LoadSource([]byte(tt.source))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadSource() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadSource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoaderExplainSource(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddFlags(flags)
+	err := flags.Parse([]string{"--tls=true"})
+	if err != nil {
+		t.Fatalf("Can not parse flags: %v", err)
+	}
+
+	_, got, err := NewLoader("", flags).ExplainSource([]byte("domain: stakater.com\ningressNameTemplate: \"{{.Service}}\"\n"))
+	if err != nil {
+		t.Fatalf("ExplainSource() error = %v", err)
+	}
+
+	want := Sources{
+		"Domain":                   SourceFile,
+		"IngressURLTemplate":       SourceDefault,
+		"IngressURLPath":           SourceDefault,
+		"IngressNameTemplate":      SourceFile,
+		"TLS":                      SourceFlag,
+		"TLSSecretNameTemplate":    SourceDefault,
+		"ClusterName":              SourceDefault,
+		"DriftPolicy":              SourceDefault,
+		"AdoptionPolicy":           SourceDefault,
+		"HostClaimScope":           SourceDefault,
+		"NormalizeIngressName":     SourceDefault,
+		"NormalizeHost":            SourceDefault,
+		"NormalizeTLSSecretName":   SourceDefault,
+		"ExposeSelector":           SourceDefault,
+		"ExposeAnnotation":         SourceDefault,
+		"NamespaceSelector":        SourceDefault,
+		"ExcludeNamespaceSelector": SourceDefault,
+		"IngressClass":             SourceDefault,
+		"Tiers":                    SourceDefault,
+		"TierLabel":                SourceDefault,
+		"DefaultAnnotations":       SourceDefault,
+		"AnnotationProfiles":       SourceDefault,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExplainSource() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"k8s.io/api/core/v1"
+)
+
+// ServiceProfiles returns the annotation profiles picked by a service with its profiles annotation, in the listed
+// order. An error is returned for unknown profiles
+func (c Configuration) ServiceProfiles(service *v1.Service) ([]string, error) {
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(service.Annotations[constants.ANNOTATION_PROFILES_ANNOTATION], ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, isProfile := c.AnnotationProfiles[name]; !isProfile {
+			return nil, fmt.Errorf("unknown annotation profile %q in annotation %v", name, constants.ANNOTATION_PROFILES_ANNOTATION)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// ProfileAnnotations returns the annotations of the profiles picked by the service, a profile listed later overrides
+// the ones listed before it
+func (c Configuration) ProfileAnnotations(service *v1.Service) (map[string]string, error) {
+	names, err := c.ServiceProfiles(service)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := make(map[string]string)
+	for _, name := range names {
+		for key, value := range c.AnnotationProfiles[name] {
+			annotations[key] = value
+		}
+	}
+
+	return annotations, nil
+}
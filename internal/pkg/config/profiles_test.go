@@ -0,0 +1,61 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConfigurationProfileAnnotations(t *testing.T) {
+	configuration := Configuration{
+		DefaultAnnotations: map[string]string{"certmanager.k8s.io/cluster-issuer": "letsencrypt"},
+		AnnotationProfiles: map[string]map[string]string{
+			"uploads": {"nginx.ingress.kubernetes.io/proxy-body-size": "50m"},
+			"office": {
+				"nginx.ingress.kubernetes.io/whitelist-source-range": "10.0.0.0/8",
+				"nginx.ingress.kubernetes.io/proxy-body-size":        "1m",
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		profiles string
+		want     map[string]string
+		wantErr  bool
+	}{
+		{
+			name: "service without profiles should get no annotations",
+			want: map[string]string{},
+		},
+		{
+			name:     "later profiles should override earlier ones",
+			profiles: "office, uploads, office",
+			want: map[string]string{
+				"nginx.ingress.kubernetes.io/whitelist-source-range": "10.0.0.0/8",
+				"nginx.ingress.kubernetes.io/proxy-body-size":        "50m",
+			},
+		},
+		{
+			name:     "unknown profiles should be an error",
+			profiles: "uploads,dmz",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &v1.Service{ObjectMeta: meta_v1.ObjectMeta{
+				Annotations: map[string]string{"xposer.stakater.com/annotation-profiles": tt.profiles},
+			}}
+			got, err := configuration.ProfileAnnotations(service)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProfileAnnotations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProfileAnnotations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
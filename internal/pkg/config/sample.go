@@ -0,0 +1,101 @@
+package config
+
+import (
+	"text/template"
+	"text/template/parse"
+
+	"github.com/stakater/Xposer/internal/pkg/templates"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	sampleService   = "sample-service"
+	sampleNamespace = "sample-namespace"
+	sampleValue     = "sample"
+)
+
+// sampleVariables returns the template variables of a sample service used to validate templates. Every label and
+// annotation which the template reads with a field chain like {{.Labels.team}} is set on the sample service, as
+// templates are expected to fail for services without it
+func sampleVariables(tmpl *template.Template, clusterName string) templates.ServiceVariables {
+	service := &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        sampleService,
+			Namespace:   sampleNamespace,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				v1.ServicePort{
+					Name: "http",
+					Port: 80,
+				},
+			},
+		},
+	}
+	namespace := &v1.Namespace{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        sampleNamespace,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+	}
+
+	sampleMaps := map[string]map[string]string{
+		"Labels":               service.Labels,
+		"Annotations":          service.Annotations,
+		"NamespaceLabels":      namespace.Labels,
+		"NamespaceAnnotations": namespace.Annotations,
+	}
+	if tmpl.Tree != nil {
+		addSampleKeys(tmpl.Tree.Root, sampleMaps)
+	}
+
+	return templates.CreateServiceVariables(service, namespace, clusterName)
+}
+
+// addSampleKeys walks the parse tree and adds every key read from one of the sample maps
+func addSampleKeys(node parse.Node, sampleMaps map[string]map[string]string) {
+	switch typed := node.(type) {
+	case *parse.ListNode:
+		if typed == nil {
+			return
+		}
+		for _, child := range typed.Nodes {
+			addSampleKeys(child, sampleMaps)
+		}
+	case *parse.ActionNode:
+		addSampleKeys(typed.Pipe, sampleMaps)
+	case *parse.PipeNode:
+		if typed == nil {
+			return
+		}
+		for _, command := range typed.Cmds {
+			addSampleKeys(command, sampleMaps)
+		}
+	case *parse.CommandNode:
+		for _, arg := range typed.Args {
+			addSampleKeys(arg, sampleMaps)
+		}
+	case *parse.FieldNode:
+		if len(typed.Ident) >= 2 {
+			if sampleMap, ok := sampleMaps[typed.Ident[0]]; ok {
+				sampleMap[typed.Ident[1]] = sampleValue
+			}
+		}
+	case *parse.IfNode:
+		addSampleKeys(&typed.BranchNode, sampleMaps)
+	case *parse.RangeNode:
+		addSampleKeys(&typed.BranchNode, sampleMaps)
+	case *parse.WithNode:
+		addSampleKeys(&typed.BranchNode, sampleMaps)
+	case *parse.BranchNode:
+		addSampleKeys(typed.Pipe, sampleMaps)
+		addSampleKeys(typed.List, sampleMaps)
+		addSampleKeys(typed.ElseList, sampleMaps)
+	case *parse.TemplateNode:
+		addSampleKeys(typed.Pipe, sampleMaps)
+	}
+}
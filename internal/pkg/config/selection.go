@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Selection decides which services are exposed, it is compiled from the selectors of a configuration
+type Selection struct {
+	services           labels.Selector
+	annotation         string
+	namespaces         labels.Selector
+	excludedNamespaces labels.Selector
+}
+
+// NewSelection parses the exposure and namespace selectors of the configuration. An empty exposure selector selects
+// no services by their labels, empty namespace selectors do not restrict the namespaces
+func NewSelection(configuration Configuration) (Selection, error) {
+	selection := Selection{annotation: configuration.ExposeAnnotation}
+
+	var err error
+	if configuration.ExposeSelector != "" {
+		selection.services, err = labels.Parse(configuration.ExposeSelector)
+		if err != nil {
+			return Selection{}, fmt.Errorf("invalid exposeSelector: %v", err)
+		}
+	}
+	if configuration.NamespaceSelector != "" {
+		selection.namespaces, err = labels.Parse(configuration.NamespaceSelector)
+		if err != nil {
+			return Selection{}, fmt.Errorf("invalid namespaceSelector: %v", err)
+		}
+	}
+	if configuration.ExcludeNamespaceSelector != "" {
+		selection.excludedNamespaces, err = labels.Parse(configuration.ExcludeNamespaceSelector)
+		if err != nil {
+			return Selection{}, fmt.Errorf("invalid excludeNamespaceSelector: %v", err)
+		}
+	}
+
+	return selection, nil
+}
+
+// ServiceSelector returns the label selector which the services to watch are listed with. Services which opt in with
+// the exposure annotation can not be selected by the API server, all services are watched then
+func (s Selection) ServiceSelector() string {
+	if s.annotation != "" || s.services == nil {
+		return ""
+	}
+
+	return s.services.String()
+}
+
+// NeedsNamespace returns true if the namespace of a service must be known to decide whether it is exposed
+func (s Selection) NeedsNamespace() bool {
+	return s.namespaces != nil || s.excludedNamespaces != nil
+}
+
+// Exposes returns true if the service opted in to be exposed, by its labels or annotation, and its namespace is
+// selected. The namespace is only needed with namespace selectors, a nil namespace is never selected by them
+func (s Selection) Exposes(service *v1.Service, namespace *v1.Namespace) bool {
+	optedIn := s.services != nil && s.services.Matches(labels.Set(service.Labels))
+	if s.annotation != "" && service.Annotations[s.annotation] == "true" {
+		optedIn = true
+	}
+	if !optedIn {
+		return false
+	}
+
+	if !s.NeedsNamespace() {
+		return true
+	}
+	if namespace == nil {
+		return false
+	}
+	if s.namespaces != nil && !s.namespaces.Matches(labels.Set(namespace.Labels)) {
+		return false
+	}
+
+	return s.excludedNamespaces == nil || !s.excludedNamespaces.Matches(labels.Set(namespace.Labels))
+}
+
+// Describe returns a human readable description of how services opt in to be exposed
+func (s Selection) Describe() string {
+	description := ""
+	if s.services != nil {
+		description = "labeled " + s.services.String()
+	}
+	if s.annotation != "" {
+		if description != "" {
+			description += " or "
+		}
+		description += "annotated " + s.annotation + "=true"
+	}
+
+	return description
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSelectionExposes(t *testing.T) {
+	newService := func(labels map[string]string, annotations map[string]string) *v1.Service {
+		return &v1.Service{ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "team", Labels: labels, Annotations: annotations}}
+	}
+	newNamespace := func(labels map[string]string) *v1.Namespace {
+		return &v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "team", Labels: labels}}
+	}
+
+	tests := []struct {
+		name         string
+		selector     string
+		annotation   string
+		namespaces   string
+		excluded     string
+		service      *v1.Service
+		namespace    *v1.Namespace
+		want         bool
+		wantSelector string
+	}{
+		{
+			name:         "default selector should expose labeled services",
+			selector:     "expose=true",
+			service:      newService(map[string]string{"expose": "true"}, nil),
+			want:         true,
+			wantSelector: "expose=true",
+		},
+		{
+			name:         "services without the label should not be exposed",
+			selector:     "expose=true",
+			service:      newService(map[string]string{"expose": "false"}, nil),
+			wantSelector: "expose=true",
+		},
+		{
+			name:         "custom selector should be used",
+			selector:     "ingress in (public,internal)",
+			service:      newService(map[string]string{"ingress": "internal"}, nil),
+			want:         true,
+			wantSelector: "ingress in (internal,public)",
+		},
+		{
+			name:       "annotated services should be exposed and all services watched",
+			selector:   "expose=true",
+			annotation: "xposer.stakater.com/expose",
+			service:    newService(nil, map[string]string{"xposer.stakater.com/expose": "true"}),
+			want:       true,
+		},
+		{
+			name:         "selected namespaces should be exposed",
+			selector:     "expose=true",
+			namespaces:   "env=prod",
+			service:      newService(map[string]string{"expose": "true"}, nil),
+			namespace:    newNamespace(map[string]string{"env": "prod"}),
+			want:         true,
+			wantSelector: "expose=true",
+		},
+		{
+			name:         "unknown namespaces should not be exposed with namespace selectors",
+			selector:     "expose=true",
+			namespaces:   "env=prod",
+			service:      newService(map[string]string{"expose": "true"}, nil),
+			wantSelector: "expose=true",
+		},
+		{
+			name:         "excluded namespaces should not be exposed",
+			selector:     "expose=true",
+			excluded:     "xposer.stakater.com/ignore=true",
+			service:      newService(map[string]string{"expose": "true"}, nil),
+			namespace:    newNamespace(map[string]string{"xposer.stakater.com/ignore": "true"}),
+			wantSelector: "expose=true",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection, err := NewSelection(Configuration{
+				ExposeSelector:           tt.selector,
+				ExposeAnnotation:         tt.annotation,
+				NamespaceSelector:        tt.namespaces,
+				ExcludeNamespaceSelector: tt.excluded,
+			})
+			if err != nil {
+				t.Fatalf("NewSelection() error = %v", err)
+			}
+
+			if got := selection.Exposes(tt.service, tt.namespace); got != tt.want {
+				t.Errorf("Exposes() = %v, want %v", got, tt.want)
+			}
+			if got := selection.ServiceSelector(); got != tt.wantSelector {
+				t.Errorf("ServiceSelector() = %q, want %q", got, tt.wantSelector)
+			}
+		})
+	}
+}
+
+func TestSelectionZeroValue(t *testing.T) {
+	service := &v1.Service{ObjectMeta: meta_v1.ObjectMeta{Labels: map[string]string{"expose": "true"}}}
+	if (Selection{}).Exposes(service, nil) {
+		t.Errorf("Exposes() of the zero value = true, want false")
+	}
+}
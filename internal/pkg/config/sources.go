@@ -0,0 +1,110 @@
+package config
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Source tells where the effective value of a setting comes from
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceFlag    Source = "flag"
+	SourceService Source = "service annotation"
+	SourceDerived Source = "derived"
+	SourceTier    Source = "tier"
+)
+
+// Sources maps the field names of Configuration, which are also the keys of config annotations, to their Source
+type Sources map[string]Source
+
+// Copy returns a copy which can be changed without changing the original
+func (s Sources) Copy() Sources {
+	sources := make(Sources, len(s))
+	for key, source := range s {
+		sources[key] = source
+	}
+
+	return sources
+}
+
+// SetFromAnnotations marks every config annotation found in the given annotations as coming from the given source
+func (s Sources) SetFromAnnotations(annotations map[string]string, source Source) {
+	for annotationKey := range annotations {
+		if strings.HasPrefix(annotationKey, constants.INGRESS_CONFIG_ANNOTATION_PREFIX) {
+			s[strings.SplitN(annotationKey, "/", 2)[1]] = source
+		}
+	}
+}
+
+// SetFromTier marks every setting the named tier of the configuration overrides as coming from the tier
+func (s Sources) SetFromTier(configuration Configuration, name string) {
+	tier, ok := configuration.Tiers[name]
+	if name == DefaultTier || !ok {
+		return
+	}
+
+	overrides := map[string]bool{
+		constants.DOMAIN:                tier.Domain != "",
+		constants.INGRESS_URL_TEMPLATE:  tier.IngressURLTemplate != "",
+		constants.INGRESS_NAME_TEMPLATE: tier.IngressNameTemplate != "",
+		"IngressClass":                  tier.IngressClass != "",
+		constants.TLS:                   tier.TLS != nil,
+		constants.SECRET_NAME_TEMPLATE:  tier.TLSSecretNameTemplate != "",
+	}
+	for key, overridden := range overrides {
+		if overridden {
+			s[key] = SourceTier
+		}
+	}
+}
+
+// Explain reads the configuration file and returns the effective configuration, together with the source of
+// every field
+func (l *Loader) Explain() (Configuration, Sources, error) {
+	source, err := ioutil.ReadFile(l.filePath)
+	if err != nil {
+		return Configuration{}, nil, err
+	}
+
+	return l.ExplainSource(source)
+}
+
+// ExplainSource returns the effective configuration for the given document, together with the source of every field
+func (l *Loader) ExplainSource(source []byte) (Configuration, Sources, error) {
+	configuration, err := l.LoadSource(source)
+	if err != nil {
+		return configuration, nil, err
+	}
+
+	var document map[string]interface{}
+	err = yaml.Unmarshal(source, &document)
+	if err != nil {
+		return configuration, nil, err
+	}
+
+	sources := make(Sources)
+	t := reflect.TypeOf(configuration)
+	for i := 0; i < t.NumField(); i++ {
+		sources[t.Field(i).Name] = SourceDefault
+		if _, ok := document[strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]]; ok {
+			sources[t.Field(i).Name] = SourceFile
+		}
+	}
+
+	if l.flags != nil {
+		for _, configurationFlag := range configurationFlags {
+			if l.flags.Changed(configurationFlag.name) {
+				sources[configurationFlag.field] = SourceFlag
+			}
+		}
+	}
+
+	return configuration, sources, nil
+}
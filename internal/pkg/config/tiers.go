@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// DefaultTier names the exposure with the settings of the configuration itself, which services without tiers get
+const DefaultTier = "default"
+
+// Tier is a named exposure with its own settings, e.g. on an internal load balancer. Empty settings are taken from
+// the configuration
+type Tier struct {
+	Domain                string            `yaml:"domain"`
+	IngressURLTemplate    string            `yaml:"ingressURLTemplate"`
+	IngressNameTemplate   string            `yaml:"ingressNameTemplate"`
+	IngressClass          string            `yaml:"ingressClass"`
+	TLS                   *bool             `yaml:"tls"`
+	TLSSecretNameTemplate string            `yaml:"tlsSecretNameTemplate"`
+	Annotations           map[string]string `yaml:"annotations"`
+}
+
+// ForTier returns the configuration used to expose services on the named tier, the settings of the tier override
+// those of the configuration. Names inherited from the configuration must be suffixed with the tier, see
+// InheritsNames, so that the objects of different tiers do not collide
+func (c Configuration) ForTier(name string) Configuration {
+	tier, ok := c.Tiers[name]
+	if name == DefaultTier || !ok {
+		return c
+	}
+
+	if tier.Domain != "" {
+		c.Domain = tier.Domain
+	}
+	if tier.IngressURLTemplate != "" {
+		c.IngressURLTemplate = tier.IngressURLTemplate
+	}
+	if tier.IngressNameTemplate != "" {
+		c.IngressNameTemplate = tier.IngressNameTemplate
+	}
+	if tier.IngressClass != "" {
+		c.IngressClass = tier.IngressClass
+	}
+	if tier.TLS != nil {
+		c.TLS = *tier.TLS
+	}
+	if tier.TLSSecretNameTemplate != "" {
+		c.TLSSecretNameTemplate = tier.TLSSecretNameTemplate
+	}
+
+	return c
+}
+
+// InheritsNames tells whether the named tier takes its ingress name and TLS secret name templates from the
+// configuration, in which case the rendered names are suffixed with the tier
+func (c Configuration) InheritsNames(name string) (ingressName bool, secretName bool) {
+	tier, ok := c.Tiers[name]
+	if name == DefaultTier || !ok {
+		return false, false
+	}
+
+	return tier.IngressNameTemplate == "", tier.TLSSecretNameTemplate == ""
+}
+
+// TierAnnotations returns the annotations forwarded to the objects generated on the named tier
+func (c Configuration) TierAnnotations(name string) map[string]string {
+	return c.Tiers[name].Annotations
+}
+
+// TierLabelKey returns the label whose value names the tier of a service: tierLabel if set, or else the label of the
+// first requirement of exposeSelector which admits the name of a tier, e.g. xposer.io/expose for
+// "xposer.io/expose in (public,internal)". It is empty if services can not pick a tier with a label
+func (c Configuration) TierLabelKey() string {
+	if c.TierLabel != "" {
+		return c.TierLabel
+	}
+
+	selector, err := labels.Parse(c.ExposeSelector)
+	if err != nil || c.ExposeSelector == "" {
+		return ""
+	}
+	requirements, _ := selector.Requirements()
+	for _, requirement := range requirements {
+		switch requirement.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In, selection.Exists:
+		default:
+			continue
+		}
+		for name := range c.Tiers {
+			if requirement.Matches(labels.Set{requirement.Key(): name}) {
+				return requirement.Key()
+			}
+		}
+	}
+
+	return ""
+}
+
+// ServiceTiers returns the tiers a service is exposed on: those listed in its tiers annotation, or the tier named by
+// the value of its tier label, see TierLabelKey, or the default tier otherwise. An error is returned for unknown tiers
+func (c Configuration) ServiceTiers(service *v1.Service) ([]string, error) {
+	listed, ok := service.Annotations[constants.TIERS_ANNOTATION]
+	if !ok || strings.TrimSpace(listed) == "" {
+		if key := c.TierLabelKey(); key != "" {
+			if _, isTier := c.Tiers[service.Labels[key]]; isTier {
+				return []string{service.Labels[key]}, nil
+			}
+		}
+		return []string{DefaultTier}, nil
+	}
+
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(listed, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, isTier := c.Tiers[name]; !isTier && name != DefaultTier {
+			return nil, fmt.Errorf("unknown tier %q in annotation %v", name, constants.TIERS_ANNOTATION)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConfigurationServiceTiers(t *testing.T) {
+	configuration := Configuration{
+		ExposeSelector: "expose in (true,internal,external)",
+		Tiers:          map[string]Tier{"internal": {}, "external": {}},
+	}
+
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		want        []string
+		wantErr     bool
+	}{
+		{
+			name:   "service labeled true should be exposed on the default tier",
+			labels: map[string]string{"expose": "true"},
+			want:   []string{DefaultTier},
+		},
+		{
+			name:   "expose label value should select a tier",
+			labels: map[string]string{"expose": "internal"},
+			want:   []string{"internal"},
+		},
+		{
+			name:        "tiers annotation should override the label",
+			labels:      map[string]string{"expose": "internal"},
+			annotations: map[string]string{"xposer.stakater.com/tiers": "external, default, external"},
+			want:        []string{"external", DefaultTier},
+		},
+		{
+			name:        "unknown tiers should be an error",
+			annotations: map[string]string{"xposer.stakater.com/tiers": "internal,dmz"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &v1.Service{ObjectMeta: meta_v1.ObjectMeta{Labels: tt.labels, Annotations: tt.annotations}}
+			got, err := configuration.ServiceTiers(service)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ServiceTiers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) && !tt.wantErr {
+				t.Errorf("ServiceTiers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigurationTierLabelKey(t *testing.T) {
+	tiers := map[string]Tier{"internal": {}, "public": {}}
+
+	tests := []struct {
+		name          string
+		configuration Configuration
+		want          string
+	}{
+		{
+			name:          "configured tier label should be used",
+			configuration: Configuration{ExposeSelector: "expose=true", TierLabel: "tier", Tiers: tiers},
+			want:          "tier",
+		},
+		{
+			name:          "tier label should be derived from the requirement admitting a tier",
+			configuration: Configuration{ExposeSelector: "team=web,xposer.io/expose in (public,internal)", Tiers: tiers},
+			want:          "xposer.io/expose",
+		},
+		{
+			name:          "selector admitting no tier should not select tiers by label",
+			configuration: Configuration{ExposeSelector: "expose=true", Tiers: tiers},
+			want:          "",
+		},
+		{
+			name:          "excluding requirement should not be the tier label",
+			configuration: Configuration{ExposeSelector: "expose=true,team notin (ops)", Tiers: tiers},
+			want:          "",
+		},
+		{
+			name:          "existence requirement should admit every tier",
+			configuration: Configuration{ExposeSelector: "xposer.io/expose", Tiers: tiers},
+			want:          "xposer.io/expose",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.configuration.TierLabelKey(); got != tt.want {
+				t.Errorf("TierLabelKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
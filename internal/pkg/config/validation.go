@@ -0,0 +1,345 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/templates"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	sampleRenderDetail = "renders to an invalid name for a sample service"
+)
+
+// Validate checks that the given configuration has everything needed to expose services
+func Validate(configuration Configuration) error {
+	return ValidateConfiguration(configuration).ToAggregate()
+}
+
+// ValidateConfiguration checks required fields, and renders every template against a sample service to make sure
+// it produces valid names and hosts
+func ValidateConfiguration(configuration Configuration) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	domainPath := field.NewPath("domain")
+	if configuration.Domain == "" {
+		allErrs = append(allErrs, field.Required(domainPath, "domain is used to render ingress hosts"))
+	} else {
+		allErrs = append(allErrs, validateDNS1123Subdomain(domainPath, configuration.Domain, "is not a valid domain")...)
+	}
+
+	urlTemplate := func(variables templates.ServiceVariables) interface{} {
+		return templates.CreateUrlTemplate(variables, configuration.Domain)
+	}
+	nameTemplate := func(variables templates.ServiceVariables) interface{} {
+		return templates.CreateNameTemplate(variables)
+	}
+	secretTemplate := func(variables templates.ServiceVariables) interface{} {
+		return templates.CreateSecretTemplate(variables)
+	}
+
+	// The part of the URL template after the first "/" is used as path, see templates.FormatURLTemplateAndDeriveURLPath
+	urlPath := field.NewPath("ingressURLTemplate")
+	hostTemplate := strings.SplitN(configuration.IngressURLTemplate, "/", 2)[0]
+	if configuration.IngressURLTemplate == "" {
+		allErrs = append(allErrs, field.Required(urlPath, "ingressURLTemplate is used to render ingress hosts"))
+	} else if host, err := dryRender(hostTemplate, urlTemplate, configuration.ClusterName); err != nil {
+		allErrs = append(allErrs, field.Invalid(urlPath, configuration.IngressURLTemplate, err.Error()))
+	} else if configuration.Domain != "" {
+		// Without a domain the host is invalid anyway, which is already reported for the domain
+		if configuration.NormalizeHost {
+			host = templates.NormalizeHost(host)
+		}
+		allErrs = append(allErrs, validateDNS1123Subdomain(urlPath, host, sampleRenderDetail)...)
+	}
+
+	// A "/" is prepended to the path if missing, see ingresses.AppendSlashInPathAnnotationIfNotPresent
+	pathPath := field.NewPath("ingressURLPath")
+	pathTemplate := configuration.IngressURLPath
+	if !strings.HasPrefix(pathTemplate, "/") {
+		pathTemplate = "/" + pathTemplate
+	}
+	if _, err := dryRender(pathTemplate, urlTemplate, configuration.ClusterName); err != nil {
+		allErrs = append(allErrs, field.Invalid(pathPath, configuration.IngressURLPath, err.Error()))
+	}
+
+	namePath := field.NewPath("ingressNameTemplate")
+	if configuration.IngressNameTemplate == "" {
+		allErrs = append(allErrs, field.Required(namePath, "ingressNameTemplate is used to render ingress names"))
+	} else if name, err := dryRender(configuration.IngressNameTemplate, nameTemplate, configuration.ClusterName); err != nil {
+		allErrs = append(allErrs, field.Invalid(namePath, configuration.IngressNameTemplate, err.Error()))
+	} else {
+		if configuration.NormalizeIngressName {
+			name = templates.NormalizeName(name)
+		}
+		allErrs = append(allErrs, validateDNS1123Subdomain(namePath, name, sampleRenderDetail)...)
+	}
+
+	// NO_SECRET makes certmanager generate the secret name from the ingress name
+	secretPath := field.NewPath("tlsSecretNameTemplate")
+	if configuration.TLSSecretNameTemplate != "" && configuration.TLSSecretNameTemplate != constants.NO_SECRET {
+		if secretName, err := dryRender(configuration.TLSSecretNameTemplate, secretTemplate, configuration.ClusterName); err != nil {
+			allErrs = append(allErrs, field.Invalid(secretPath, configuration.TLSSecretNameTemplate, err.Error()))
+		} else {
+			if configuration.NormalizeTLSSecretName {
+				secretName = templates.NormalizeName(secretName)
+			}
+			allErrs = append(allErrs, validateDNS1123Subdomain(secretPath, secretName, sampleRenderDetail)...)
+		}
+	}
+
+	driftPolicies := []string{DriftPolicyRevert, DriftPolicyReport, DriftPolicyIgnore}
+	if !containsString(driftPolicies, configuration.DriftPolicy) {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("driftPolicy"), configuration.DriftPolicy, driftPolicies))
+	}
+
+	adoptionPolicies := []string{AdoptionPolicyRefuse, AdoptionPolicyAdopt, AdoptionPolicyOverwrite}
+	if !containsString(adoptionPolicies, configuration.AdoptionPolicy) {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("adoptionPolicy"), configuration.AdoptionPolicy, adoptionPolicies))
+	}
+
+	hostClaimScopes := []string{HostClaimScopeManaged, HostClaimScopeAll}
+	if !containsString(hostClaimScopes, configuration.HostClaimScope) {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("hostClaimScope"), configuration.HostClaimScope, hostClaimScopes))
+	}
+
+	allErrs = append(allErrs, validateSelection(configuration)...)
+	allErrs = append(allErrs, validateTiers(configuration)...)
+	allErrs = append(allErrs, validateAnnotations(configuration)...)
+
+	return allErrs
+}
+
+// validateTiers checks the names of the tiers, which are used as suffixes of names, and the settings they override,
+// which are validated like those of the configuration. Errors of inherited settings are reported for the
+// configuration already
+func validateTiers(configuration Configuration) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	tiersPath := field.NewPath("tiers")
+	for name, tier := range configuration.Tiers {
+		tierPath := tiersPath.Key(name)
+		if name == DefaultTier {
+			allErrs = append(allErrs, field.Invalid(tierPath, name, "is reserved for the exposure with the settings of the configuration"))
+			continue
+		}
+		for _, msg := range validation.IsDNS1123Label(name) {
+			allErrs = append(allErrs, field.Invalid(tierPath, name, msg))
+		}
+
+		overridden := map[string]bool{
+			"domain":                tier.Domain != "",
+			"ingressURLTemplate":    tier.IngressURLTemplate != "",
+			"ingressNameTemplate":   tier.IngressNameTemplate != "",
+			"tlsSecretNameTemplate": tier.TLSSecretNameTemplate != "",
+		}
+		tierConfiguration := configuration.ForTier(name)
+		tierConfiguration.Tiers = nil
+		for _, fieldErr := range ValidateConfiguration(tierConfiguration) {
+			if overridden[fieldErr.Field] {
+				fieldErr.Field = tierPath.Child(fieldErr.Field).String()
+				allErrs = append(allErrs, fieldErr)
+			}
+		}
+
+		allErrs = append(allErrs, validateAnnotationKeys(tierPath.Child("annotations"), tier.Annotations)...)
+	}
+
+	allErrs = append(allErrs, validateTierLabel(configuration)...)
+
+	return allErrs
+}
+
+// validateTierLabel checks that services can pick every tier with the configured tier label, which exposeSelector
+// must admit as value of the label. A derived tier label is admitted by exposeSelector already, see TierLabelKey
+func validateTierLabel(configuration Configuration) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if configuration.TierLabel == "" {
+		return allErrs
+	}
+
+	tierLabelPath := field.NewPath("tierLabel")
+	for _, msg := range validation.IsQualifiedName(configuration.TierLabel) {
+		allErrs = append(allErrs, field.Invalid(tierLabelPath, configuration.TierLabel, msg))
+	}
+
+	selector, err := labels.Parse(configuration.ExposeSelector)
+	if err != nil || configuration.ExposeSelector == "" {
+		// Errors of exposeSelector are reported by validateSelection
+		return allErrs
+	}
+	requirements, _ := selector.Requirements()
+	names := []string{}
+	for name := range configuration.Tiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, requirement := range requirements {
+			if requirement.Key() == configuration.TierLabel && !requirement.Matches(labels.Set{configuration.TierLabel: name}) {
+				allErrs = append(allErrs, field.Invalid(tierLabelPath, configuration.TierLabel,
+					fmt.Sprintf("exposeSelector %q does not select services labeled %v=%v, which can not be exposed on tier %v",
+						configuration.ExposeSelector, configuration.TierLabel, name, name)))
+				break
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateAnnotations checks the keys of the default annotations and of the annotation profiles, and the names of the
+// profiles, which are listed in a comma separated annotation
+func validateAnnotations(configuration Configuration) field.ErrorList {
+	allErrs := validateAnnotationKeys(field.NewPath("defaultAnnotations"), configuration.DefaultAnnotations)
+
+	profilesPath := field.NewPath("annotationProfiles")
+	for name, annotations := range configuration.AnnotationProfiles {
+		profilePath := profilesPath.Key(name)
+		for _, msg := range validation.IsDNS1123Label(name) {
+			allErrs = append(allErrs, field.Invalid(profilePath, name, msg))
+		}
+		allErrs = append(allErrs, validateAnnotationKeys(profilePath, annotations)...)
+	}
+
+	return allErrs
+}
+
+// validateAnnotationKeys checks that the keys of annotations forwarded to generated objects are qualified names
+func validateAnnotationKeys(fldPath *field.Path, annotations map[string]string) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for key := range annotations {
+		for _, msg := range validation.IsQualifiedName(key) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(key), key, msg))
+		}
+	}
+
+	return allErrs
+}
+
+// validateSelection checks that services can opt in to be exposed, and that the selectors parse
+func validateSelection(configuration Configuration) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if configuration.ExposeSelector == "" && configuration.ExposeAnnotation == "" {
+		allErrs = append(allErrs, field.Required(field.NewPath("exposeSelector"),
+			"exposeSelector or exposeAnnotation is needed for services to opt in to be exposed"))
+	}
+	selectors := map[string]string{
+		"exposeSelector":           configuration.ExposeSelector,
+		"namespaceSelector":        configuration.NamespaceSelector,
+		"excludeNamespaceSelector": configuration.ExcludeNamespaceSelector,
+	}
+	for _, name := range []string{"exposeSelector", "namespaceSelector", "excludeNamespaceSelector"} {
+		if _, err := labels.Parse(selectors[name]); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath(name), selectors[name], err.Error()))
+		}
+	}
+	if configuration.ExposeAnnotation != "" {
+		for _, msg := range validation.IsQualifiedName(configuration.ExposeAnnotation) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("exposeAnnotation"), configuration.ExposeAnnotation, msg))
+		}
+	}
+
+	return allErrs
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dryRender parses the given template and executes it against the data created for a sample service
+func dryRender(templateToParse string, createData func(templates.ServiceVariables) interface{}, clusterName string) (string, error) {
+	tmpl, err := templates.Parse("validation", templateToParse)
+	if err != nil {
+		return "", err
+	}
+
+	return templates.Execute(tmpl, templateToParse, createData(sampleVariables(tmpl, clusterName)))
+}
+
+func validateDNS1123Subdomain(fldPath *field.Path, value string, detail string) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsDNS1123Subdomain(value) {
+		allErrs = append(allErrs, field.Invalid(fldPath, value, fmt.Sprintf("%v: %v", detail, msg)))
+	}
+
+	return allErrs
+}
+
+// validateKnownKeys reports every key of the document which has no matching yaml field in the given type
+func validateKnownKeys(document interface{}, t reflect.Type, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		values := toStringMap(document)
+		fields := make(map[string]reflect.StructField)
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+			if name != "" && name != "-" {
+				fields[name] = t.Field(i)
+			}
+		}
+
+		for key, value := range values {
+			structField, ok := fields[key]
+			if !ok {
+				allErrs = append(allErrs, field.Forbidden(childPath(fldPath, key), "unknown configuration key"))
+				continue
+			}
+			allErrs = append(allErrs, validateKnownKeys(value, structField.Type, childPath(fldPath, key))...)
+		}
+	case reflect.Map:
+		for key, value := range toStringMap(document) {
+			allErrs = append(allErrs, validateKnownKeys(value, t.Elem(), fldPath.Key(key))...)
+		}
+	case reflect.Slice:
+		if values, ok := document.([]interface{}); ok {
+			for i, value := range values {
+				allErrs = append(allErrs, validateKnownKeys(value, t.Elem(), fldPath.Index(i))...)
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func toStringMap(document interface{}) map[string]interface{} {
+	values := make(map[string]interface{})
+
+	switch typed := document.(type) {
+	case map[string]interface{}:
+		values = typed
+	case map[interface{}]interface{}:
+		for key, value := range typed {
+			values[fmt.Sprintf("%v", key)] = value
+		}
+	}
+
+	return values
+}
+
+func childPath(fldPath *field.Path, name string) *field.Path {
+	if fldPath == nil {
+		return field.NewPath(name)
+	}
+
+	return fldPath.Child(name)
+}
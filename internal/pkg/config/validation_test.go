@@ -0,0 +1,194 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestLoaderValidateSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		wantFields []string
+		wantErr    bool
+	}{
+		{
+			name:       "valid config should have no errors",
+			source:     validConfigContent + "tls: true\ntlsSecretNameTemplate: \"{{.Service}}-tls\"\n",
+			wantFields: []string{},
+		},
+		{
+			name:       "NO_SECRET should be accepted as secret name template",
+			source:     validConfigContent + "tlsSecretNameTemplate: NO_SECRET\n",
+			wantFields: []string{},
+		},
+		{
+			name:       "unknown keys should be reported",
+			source:     validConfigContent + "domian: stakater.com\n",
+			wantFields: []string{"domian"},
+		},
+		{
+			name:       "missing domain should be reported",
+			source:     "tls: true\n",
+			wantFields: []string{"domain"},
+		},
+		{
+			name:       "emptied required fields should be reported",
+			source:     "domain: stakater.com\ningressURLTemplate: \"\"\ningressNameTemplate: \"\"\n",
+			wantFields: []string{"ingressURLTemplate", "ingressNameTemplate"},
+		},
+		{
+			name: "unknown template variables should be reported",
+			source: `domain: stakater.com
+ingressURLTemplate: "{{.Servce}}.{{.Domain}}"
+ingressNameTemplate: "{{.Service}}"
+`,
+			wantFields: []string{"ingressURLTemplate"},
+		},
+		{
+			name: "unparsable templates should be reported",
+			source: `domain: stakater.com
+ingressURLTemplate: "{{.Service}}.{{.Domain}}"
+ingressNameTemplate: "{{.Service"
+`,
+			wantFields: []string{"ingressNameTemplate"},
+		},
+		{
+			name: "invalid rendered names should be reported without normalization",
+			source: `domain: stakater.com
+ingressURLTemplate: "{{.Service}}.{{.Domain}}"
+ingressNameTemplate: "{{.Service}}_{{.Namespace}}"
+tlsSecretNameTemplate: "{{.Service}}.-tls"
+normalizeIngressName: false
+normalizeTLSSecretName: false
+`,
+			wantFields: []string{"ingressNameTemplate", "tlsSecretNameTemplate"},
+		},
+		{
+			name: "invalid rendered names should be normalized",
+			source: `domain: stakater.com
+ingressURLTemplate: "{{.Service}}_{{.Namespace}}.{{.Domain}}"
+ingressNameTemplate: "{{.Service}}_{{.Namespace}}"
+tlsSecretNameTemplate: "{{.Service}}.-tls"
+`,
+			wantFields: []string{},
+		},
+		{
+			name: "path after the host in URL template should not be validated as a host",
+			source: `domain: stakater.com
+ingressURLTemplate: "{{.Service}}.{{.Domain}}/{{.Namespace}}"
+ingressNameTemplate: "{{.Service}}"
+`,
+			wantFields: []string{},
+		},
+		{
+			name: "labels, annotations and cluster variables should be available in all templates",
+			source: `domain: stakater.com
+clusterName: prod
+ingressURLTemplate: "{{.Service}}.{{.Labels.team}}.{{.NamespaceLabels.env}}.{{.ClusterName}}.{{.Domain}}"
+ingressNameTemplate: "{{.Service}}-{{.PortName}}-{{.Port}}"
+tlsSecretNameTemplate: "{{.Annotations.secret}}"
+`,
+			wantFields: []string{},
+		},
+		{
+			name: "domain should not be available in name template",
+			source: `domain: stakater.com
+ingressURLTemplate: "{{.Service}}.{{.Domain}}"
+ingressNameTemplate: "{{.Service}}-{{.Domain}}"
+`,
+			wantFields: []string{"ingressNameTemplate"},
+		},
+		{
+			name: "invalid selectors should be reported",
+			source: validConfigContent + `namespaceSelector: "env in (prod"
+excludeNamespaceSelector: "!"
+exposeAnnotation: "not an annotation"
+`,
+			wantFields: []string{"namespaceSelector", "excludeNamespaceSelector", "exposeAnnotation"},
+		},
+		{
+			name:       "services should be able to opt in",
+			source:     validConfigContent + "exposeSelector: \"\"\n",
+			wantFields: []string{"exposeSelector"},
+		},
+		{
+			name:       "annotation should be enough to opt in",
+			source:     validConfigContent + "exposeSelector: \"\"\nexposeAnnotation: xposer.stakater.com/expose\n",
+			wantFields: []string{},
+		},
+		{
+			name: "tiers should be validated",
+			source: validConfigContent + `tiers:
+  internal:
+    domain: internal.example.com
+    ingressClass: nginx-internal
+    tls: false
+    annotations:
+      nginx.ingress.kubernetes.io/whitelist-source-range: 10.0.0.0/8
+  Public:
+    domain: Not_A_Domain
+  default:
+    domain: example.com
+`,
+			wantFields: []string{"tiers[Public]", "tiers[Public].domain", "tiers[default]"},
+		},
+		{
+			name:       "unknown tier keys should be reported",
+			source:     validConfigContent + "tiers:\n  internal:\n    domian: internal.example.com\n",
+			wantFields: []string{"tiers[internal].domian"},
+		},
+		{
+			name:       "tier label admitted by the expose selector should be valid",
+			source:     validConfigContent + "exposeSelector: xposer.io/expose in (public,internal)\ntierLabel: xposer.io/expose\ntiers:\n  public: {}\n  internal: {}\n",
+			wantFields: []string{},
+		},
+		{
+			name:       "tier not admitted by the expose selector should be reported",
+			source:     validConfigContent + "exposeSelector: xposer.io/expose in (public,internal)\ntierLabel: xposer.io/expose\ntiers:\n  public: {}\n  edge: {}\n",
+			wantFields: []string{"tierLabel"},
+		},
+		{
+			name: "default annotations and profiles should be validated",
+			source: validConfigContent + `defaultAnnotations:
+  certmanager.k8s.io/cluster-issuer: letsencrypt
+  not a key: "true"
+annotationProfiles:
+  large-uploads:
+    nginx.ingress.kubernetes.io/proxy-body-size: 50m
+  Office_Only:
+    nginx.ingress.kubernetes.io/whitelist-source-range: 10.0.0.0/8
+`,
+			wantFields: []string{"defaultAnnotations[not a key]", "annotationProfiles[Office_Only]"},
+		},
+		{
+			name:    "unparsable document should return an error",
+			source:  "domain: [",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, allErrs, err := NewLoader("", nil).ValidateSource([]byte(tt.source))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSource() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			gotFields := make(map[string]bool)
+			for _, fieldErr := range allErrs {
+				gotFields[fieldErr.Field] = true
+			}
+			if len(gotFields) != len(tt.wantFields) {
+				t.Errorf("ValidateSource() errors = %v, want errors for %v", allErrs, tt.wantFields)
+			}
+			for _, wantField := range tt.wantFields {
+				if !gotFields[wantField] {
+					t.Errorf("ValidateSource() errors = %v, want error for %v", allErrs, wantField)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Watcher polls the configuration file and hands every new valid version of it to a handler, which may reject it.
+// Polling the content instead of relying on file events keeps it working when the file is a ConfigMap volume, where
+// the kubelet swaps a symlink to a new directory instead of writing to the file.
+type Watcher struct {
+	loader   *Loader
+	period   time.Duration
+	checksum [sha256.Size]byte
+	onChange func(Configuration, Sources) error
+}
+
+// NewWatcher creates a Watcher for the file of the given loader, the current content of the file is considered as
+// already loaded. The handler also gets the source of every field of the new configuration
+func NewWatcher(loader *Loader, period time.Duration, onChange func(Configuration, Sources) error) *Watcher {
+	watcher := &Watcher{
+		loader:   loader,
+		period:   period,
+		onChange: onChange,
+	}
+
+	source, err := ioutil.ReadFile(loader.FilePath())
+	if err == nil {
+		watcher.checksum = sha256.Sum256(source)
+	}
+
+	return watcher
+}
+
+// Run checks the file for changes until stopCh is closed
+func (w *Watcher) Run(stopCh <-chan struct{}) {
+	wait.Until(w.check, w.period, stopCh)
+}
+
+func (w *Watcher) check() {
+	source, err := ioutil.ReadFile(w.loader.FilePath())
+	if err != nil {
+		// The file can briefly disappear while a ConfigMap volume is being updated
+		logrus.Warnf("Can not read configuration file: %v, keeping the current configuration", err)
+		return
+	}
+
+	checksum := sha256.Sum256(source)
+	if checksum == w.checksum {
+		return
+	}
+	w.checksum = checksum
+
+	configuration, sources, err := w.loader.ExplainSource(source)
+	if err != nil {
+		logrus.Errorf("Changed configuration file is invalid, keeping the current configuration: %v", err)
+		return
+	}
+
+	logrus.Infof("Configuration file %v changed, reloading configuration", w.loader.FilePath())
+	if err := w.onChange(configuration, sources); err != nil {
+		logrus.Errorf("Changed configuration can not be applied, keeping the current configuration: %v", err)
+	}
+}
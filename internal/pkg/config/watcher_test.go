@@ -0,0 +1,76 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcherCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		wantChanged bool
+	}{
+		{
+			name:        "unchanged file should not reload",
+			content:     validConfigContent,
+			wantChanged: false,
+		},
+		{
+			name:        "valid change should reload",
+			content:     validConfigContent + "tls: true\n",
+			wantChanged: true,
+		},
+		{
+			name:        "invalid change should not reload",
+			content:     "domain: \"\"\n",
+			wantChanged: false,
+		},
+		{
+			name:        "unparsable change should not reload",
+			content:     "domain: [",
+			wantChanged: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "xposer-config")
+			if err != nil {
+				t.Fatalf("Can not create temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+
+			filePath := filepath.Join(dir, "config.yaml")
+			writeFile(t, filePath, validConfigContent)
+
+			changed := false
+			watcher := NewWatcher(NewLoader(filePath, nil), time.Second, func(Configuration, Sources) error {
+				changed = true
+				return nil
+			})
+
+			writeFile(t, filePath, tt.content)
+			watcher.check()
+
+			if changed != tt.wantChanged {
+				t.Errorf("Watcher.check() changed = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
+
+const validConfigContent = `domain: stakater.com
+ingressURLTemplate: "{{.Service}}.{{.Namespace}}.{{.Domain}}"
+ingressURLPath: /
+ingressNameTemplate: "{{.Service}}"
+`
+
+func writeFile(t *testing.T, filePath string, content string) {
+	err := ioutil.WriteFile(filePath, []byte(content), 0644)
+	if err != nil {
+		t.Fatalf("Can not write file: %v", err)
+	}
+}
@@ -1,114 +1,267 @@
 package configmaps
 
 import (
+	"strings"
+
+	"github.com/stakater/Xposer/internal/pkg/config"
 	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ownership"
+	"github.com/stakater/Xposer/internal/pkg/scope"
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/retry"
 )
 
 // CreateConfigMapObject creates a *v1.Configmap object from given parameters
 func CreateConfigMapObject(namespace string, configData map[string]string) *v1.ConfigMap {
-	return &v1.ConfigMap{
+	configMap := &v1.ConfigMap{
 		ObjectMeta: meta_v1.ObjectMeta{
 			Name:      constants.XPOSER_CONFIGMAP,
 			Namespace: namespace,
 		},
 		Data: configData,
 	}
+
+	// The configmap is shared by all services of the namespace, so it has no owner
+	ownership.MarkManaged(&configMap.ObjectMeta)
+
+	return configMap
 }
 
-// DeleteFromConfigMapGlobally generates configmap key from given service, and removes that key from xposer configmap from all namespaces
-func DeleteFromConfigMapGlobally(clientset kubernetes.Interface, service *v1.Service) {
-	namespaces, err := clientset.CoreV1().Namespaces().List(meta_v1.ListOptions{})
+// ConfigMapKey returns the key under which the URL of the given service is published in xposer configmaps
+func ConfigMapKey(service *v1.Service) string {
+	return service.Name + "-" + service.Namespace
+}
+
+// ConfigMapTierKey returns the key under which the URL of the given service on a tier is published, the key of the
+// default tier is the key of the service
+func ConfigMapTierKey(service *v1.Service, tier string) string {
+	if tier == "" || tier == config.DefaultTier {
+		return ConfigMapKey(service)
+	}
+
+	return ConfigMapKey(service) + "." + tier
+}
+
+// IsServiceKey returns true if the key holds a URL of the given service, on any tier
+func IsServiceKey(key string, service *v1.Service) bool {
+	return key == ConfigMapKey(service) || strings.HasPrefix(key, ConfigMapKey(service)+".")
+}
+
+// Listers serve the reads of namespaces and xposer configmaps from the caches of shared informers. A nil lister means
+// that the cache is not available, e.g. without the permission to watch, and the API server is asked instead
+type Listers struct {
+	Namespaces corelisters.NamespaceLister
+	ConfigMaps corelisters.ConfigMapLister
+	// ConfigMapsNamespace is the only namespace whose configmaps are cached, all are if empty
+	ConfigMapsNamespace string
+	// Scope holds the watched namespaces, URLs are only published globally in them
+	Scope scope.Scope
+}
+
+// DeleteFromConfigMapGlobally generates configmap key from given service, and removes that key, and those of its tiers, from xposer configmap from all namespaces
+func DeleteFromConfigMapGlobally(clientset kubernetes.Interface, listers Listers, service *v1.Service) {
+	namespaces, err := listNamespaces(clientset, listers)
 	if err != nil {
 		logrus.Errorf("Can not fetch all namespaces: %v", err)
 	} else {
-		for _, namespace := range namespaces.Items {
-			configMap, err := clientset.CoreV1().ConfigMaps(namespace.Name).Get(constants.XPOSER_CONFIGMAP, meta_v1.GetOptions{})
+		for _, namespace := range namespaces {
+			configMap, err := getConfigMap(clientset, listers, namespace)
 			// configmap exist
 			if err == nil {
-				deleteKeyFromConfigMap(configMap, service, clientset, namespace.Name)
+				deleteKeyFromConfigMap(configMap, service, clientset, namespace)
 			}
 		}
 	}
 }
 
-// DeleteFromConfigMapLocally generates configmap key from given service, and removes that key from xposer configmap in service's namespace
-func DeleteFromConfigMapLocally(clientset kubernetes.Interface, service *v1.Service) {
-	configMap, err := clientset.CoreV1().ConfigMaps(service.Namespace).Get(constants.XPOSER_CONFIGMAP, meta_v1.GetOptions{})
+// DeleteFromConfigMapLocally generates configmap key from given service, and removes that key, and those of its tiers, from xposer configmap in service's namespace
+func DeleteFromConfigMapLocally(clientset kubernetes.Interface, listers Listers, service *v1.Service) {
+	configMap, err := getConfigMap(clientset, listers, service.Namespace)
 	// configmap exist
 	if err == nil {
 		deleteKeyFromConfigMap(configMap, service, clientset, service.Namespace)
 	}
 }
 
-// PopulateConfigMapGlobally creates a new/update existing xposer configmap in all namespaces
-func PopulateConfigMapGlobally(clientset kubernetes.Interface, newServiceObject *v1.Service, ingressHost string) {
-	namespaces, err := clientset.CoreV1().Namespaces().List(meta_v1.ListOptions{})
+// PopulateConfigMapGlobally creates a new/update existing xposer configmap in all namespaces. The urls are keyed by
+// ConfigMapTierKey, the other keys of the service, e.g. of a tier it is not exposed on anymore, are removed
+func PopulateConfigMapGlobally(clientset kubernetes.Interface, listers Listers, newServiceObject *v1.Service, urls map[string]string) {
+	namespaces, err := listNamespaces(clientset, listers)
 	if err != nil {
 		logrus.Errorf("Can not fetch all namespaces: %v", err)
 	} else {
-		for _, namespace := range namespaces.Items {
-			configMap, err := clientset.CoreV1().ConfigMaps(namespace.Name).Get(constants.XPOSER_CONFIGMAP, meta_v1.GetOptions{})
+		for _, namespace := range namespaces {
+			configMap, err := getConfigMap(clientset, listers, namespace)
 			if err != nil {
-				createConfigMap(clientset, newServiceObject, ingressHost, namespace.Name)
+				createConfigMap(clientset, urls, namespace)
 			} else {
-				updateConfigMap(configMap, clientset, newServiceObject, ingressHost, namespace.Name)
+				updateConfigMap(configMap, clientset, newServiceObject, urls, namespace)
 			}
 		}
 	}
 }
 
-// PopulateConfigMapLocally creates a new/update existing xposer configmap in service's namespace
-func PopulateConfigMapLocally(clientset kubernetes.Interface, newServiceObject *v1.Service, ingressHost string) {
-	configMap, err := clientset.CoreV1().ConfigMaps(newServiceObject.Namespace).Get(constants.XPOSER_CONFIGMAP, meta_v1.GetOptions{})
+// PopulateConfigMapLocally creates a new/update existing xposer configmap in service's namespace, like
+// PopulateConfigMapGlobally
+func PopulateConfigMapLocally(clientset kubernetes.Interface, listers Listers, newServiceObject *v1.Service, urls map[string]string) {
+	configMap, err := getConfigMap(clientset, listers, newServiceObject.Namespace)
 	if err != nil {
-		createConfigMap(clientset, newServiceObject, ingressHost, newServiceObject.Namespace)
+		createConfigMap(clientset, urls, newServiceObject.Namespace)
 	} else {
-		updateConfigMap(configMap, clientset, newServiceObject, ingressHost, newServiceObject.Namespace)
+		updateConfigMap(configMap, clientset, newServiceObject, urls, newServiceObject.Namespace)
+	}
+}
+
+// listNamespaces returns the names of all watched namespaces. Listed namespaces are returned as they are without a
+// selector, so that namespaces do not need to be listed
+func listNamespaces(clientset kubernetes.Interface, listers Listers) ([]string, error) {
+	if names := listers.Scope.Names(); names != nil && !listers.Scope.NeedsNamespace() {
+		return names, nil
+	}
+
+	names := []string{}
+
+	if listers.Namespaces != nil {
+		namespaces, err := listers.Namespaces.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, namespace := range namespaces {
+			if listers.Scope.Includes(namespace.Name, namespace) {
+				names = append(names, namespace.Name)
+			}
+		}
+		return names, nil
+	}
+
+	namespaces, err := clientset.CoreV1().Namespaces().List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
 	}
+	for i := range namespaces.Items {
+		if listers.Scope.Includes(namespaces.Items[i].Name, &namespaces.Items[i]) {
+			names = append(names, namespaces.Items[i].Name)
+		}
+	}
+	return names, nil
+}
+
+// getConfigMap returns a copy of the xposer configmap of a namespace, which may be changed
+func getConfigMap(clientset kubernetes.Interface, listers Listers, namespace string) (*v1.ConfigMap, error) {
+	if listers.ConfigMaps != nil && (listers.ConfigMapsNamespace == "" || listers.ConfigMapsNamespace == namespace) {
+		configMap, err := listers.ConfigMaps.ConfigMaps(namespace).Get(constants.XPOSER_CONFIGMAP)
+		if err != nil {
+			return nil, err
+		}
+		return configMap.DeepCopy(), nil
+	}
+
+	return clientset.CoreV1().ConfigMaps(namespace).Get(constants.XPOSER_CONFIGMAP, meta_v1.GetOptions{})
 }
 
 // createConfigMap uses kubernetes client to create an actual config-map in cluster
-func createConfigMap(clientset kubernetes.Interface, newServiceObject *v1.Service, ingressHost string, namespace string) {
+func createConfigMap(clientset kubernetes.Interface, urls map[string]string, namespace string) {
 	configData := make(map[string]string)
-	configData[newServiceObject.Name+"-"+newServiceObject.Namespace] = ingressHost
+	for key, url := range urls {
+		configData[key] = url
+	}
 
-	configMap := CreateConfigMapObject(newServiceObject.Namespace, configData)
+	configMap := CreateConfigMapObject(namespace, configData)
 
 	_, err := clientset.CoreV1().ConfigMaps(namespace).Create(configMap)
 
 	if err != nil {
 		logrus.Errorf("Config-map not created in namespace:%v, with error %v", namespace, err)
+		return
 	}
 
 	logrus.Infof("Configmap created in namespace: %v", namespace)
 }
 
 // updateConfigMap uses kubernetes client to update an actual config-map in cluster
-func updateConfigMap(configMap *v1.ConfigMap, clientset kubernetes.Interface, newServiceObject *v1.Service, ingressHost string, namespace string) {
-	if configMap.Data == nil {
-		configMap.Data = make(map[string]string)
-	}
-	configMap.Data[newServiceObject.Name+"-"+newServiceObject.Namespace] = ingressHost
-	_, err := clientset.CoreV1().ConfigMaps(namespace).Update(configMap)
+func updateConfigMap(configMap *v1.ConfigMap, clientset kubernetes.Interface, newServiceObject *v1.Service, urls map[string]string, namespace string) {
+	written, err := writeConfigMap(configMap, clientset, namespace, func(configMap *v1.ConfigMap) {
+		// A configmap created before Xposer labeled them is labeled now, so that cleanup finds its keys
+		ownership.MarkManaged(&configMap.ObjectMeta)
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+		for key := range configMap.Data {
+			if _, ok := urls[key]; !ok && IsServiceKey(key, newServiceObject) {
+				delete(configMap.Data, key)
+			}
+		}
+		for key, url := range urls {
+			configMap.Data[key] = url
+		}
+	})
 	if err != nil {
 		logrus.Errorf("Can not update config map in namespace: %v, with error: %v", namespace, err)
+	} else if written {
+		logrus.Infof("Configmap updated in namespace: %v", namespace)
 	}
-
-	logrus.Infof("Configmap updated in namespace: %v", namespace)
 }
 
 // deleteKeyFromConfigMap uses kubernetes client to delete a key from xposer config-map in cluster
 func deleteKeyFromConfigMap(configMap *v1.ConfigMap, service *v1.Service, clientset kubernetes.Interface, namespace string) {
-	delete(configMap.Data, service.Name+"-"+service.Namespace)
-	_, err := clientset.CoreV1().ConfigMaps(namespace).Update(configMap)
+	written, err := writeConfigMap(configMap, clientset, namespace, func(configMap *v1.ConfigMap) {
+		for key := range configMap.Data {
+			if IsServiceKey(key, service) {
+				delete(configMap.Data, key)
+			}
+		}
+	})
 	if err != nil {
 		logrus.Errorf("Can not update config map in namespace: %v, with error: %v", namespace, err)
+	} else if written {
+		logrus.Infof("Configmap updated in namespace: %v", namespace)
+	}
+}
+
+// writeConfigMap changes and updates a configmap, written is false if the change left its labels and data as they
+// were and nothing was sent. A configmap read from a cache may be outdated, the update then conflicts and is retried
+// with the configmap read from the API server
+func writeConfigMap(configMap *v1.ConfigMap, clientset kubernetes.Interface, namespace string, change func(*v1.ConfigMap)) (written bool, err error) {
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		unchanged := configMap.DeepCopy()
+		change(configMap)
+		if equalStringMaps(unchanged.Labels, configMap.Labels) && equalStringMaps(unchanged.Data, configMap.Data) {
+			written = false
+			return nil
+		}
+
+		written = true
+		_, err := clientset.CoreV1().ConfigMaps(namespace).Update(configMap)
+		if errors.IsConflict(err) {
+			latest, getErr := clientset.CoreV1().ConfigMaps(namespace).Get(constants.XPOSER_CONFIGMAP, meta_v1.GetOptions{})
+			if getErr != nil {
+				return getErr
+			}
+			configMap = latest
+		}
+		return err
+	})
+
+	return written, err
+}
+
+// equalStringMaps returns true if both maps hold the same entries, a nil map equals an empty one
+func equalStringMaps(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
 	}
 
-	logrus.Infof("Configmap updated in namespace: %v", namespace)
+	return true
 }
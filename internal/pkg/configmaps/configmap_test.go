@@ -0,0 +1,135 @@
+package configmaps
+
+import (
+	"testing"
+
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ownership"
+	"github.com/stakater/Xposer/internal/pkg/scope"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPopulateConfigMapGlobally(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    string
+		selector string
+		want     []string
+	}{
+		{
+			name: "URL should be published in all namespaces",
+			want: []string{"team", "other", "kube-system"},
+		},
+		{
+			name:  "URL should only be published in listed namespaces",
+			names: "team,other",
+			want:  []string{"team", "other"},
+		},
+		{
+			name:     "URL should only be published in selected namespaces",
+			selector: "xposer=enabled",
+			want:     []string{"team"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(
+				&v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "team", Labels: map[string]string{"xposer": "enabled"}}},
+				&v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "other"}},
+				&v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "kube-system"}},
+			)
+			watched, err := scope.Parse(tt.names, tt.selector)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			service := &v1.Service{ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "team"}}
+
+			PopulateConfigMapGlobally(clientset, Listers{Scope: watched}, service, map[string]string{ConfigMapKey(service): "app.example.com"})
+
+			configMaps, err := clientset.CoreV1().ConfigMaps("").List(meta_v1.ListOptions{})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			got := map[string]bool{}
+			for _, configMap := range configMaps.Items {
+				if configMap.Name == constants.XPOSER_CONFIGMAP && configMap.Data[ConfigMapKey(service)] == "app.example.com" {
+					got[configMap.Namespace] = true
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("PopulateConfigMapGlobally() published in %v, want %v", got, tt.want)
+			}
+			for _, namespace := range tt.want {
+				if !got[namespace] {
+					t.Errorf("PopulateConfigMapGlobally() published in %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPopulateConfigMapLocallyWithTiers(t *testing.T) {
+	service := &v1.Service{ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "team"}}
+	other := &v1.Service{ObjectMeta: meta_v1.ObjectMeta{Name: "web", Namespace: "team"}}
+	clientset := fake.NewSimpleClientset(CreateConfigMapObject("team", map[string]string{
+		ConfigMapKey(service):                 "app.example.com",
+		ConfigMapTierKey(service, "internal"): "app.internal.example.com",
+		ConfigMapTierKey(other, "internal"):   "web.internal.example.com",
+	}))
+
+	// The service left the internal tier and joined the public one
+	PopulateConfigMapLocally(clientset, Listers{}, service, map[string]string{
+		ConfigMapKey(service):               "app.example.com",
+		ConfigMapTierKey(service, "public"): "app.public.example.com",
+	})
+
+	configMap, err := clientset.CoreV1().ConfigMaps("team").Get(constants.XPOSER_CONFIGMAP, meta_v1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	want := map[string]string{
+		"app-team":          "app.example.com",
+		"app-team.public":   "app.public.example.com",
+		"web-team.internal": "web.internal.example.com",
+	}
+	if len(configMap.Data) != len(want) {
+		t.Errorf("PopulateConfigMapLocally() data = %v, want %v", configMap.Data, want)
+	}
+	for key, value := range want {
+		if configMap.Data[key] != value {
+			t.Errorf("PopulateConfigMapLocally() data = %v, want %v", configMap.Data, want)
+		}
+	}
+
+	DeleteFromConfigMapLocally(clientset, Listers{}, service)
+	configMap, err = clientset.CoreV1().ConfigMaps("team").Get(constants.XPOSER_CONFIGMAP, meta_v1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(configMap.Data) != 1 || configMap.Data["web-team.internal"] == "" {
+		t.Errorf("DeleteFromConfigMapLocally() data = %v, want only the keys of other services", configMap.Data)
+	}
+}
+
+func TestPopulateConfigMapLocallyLabelsExistingConfigMap(t *testing.T) {
+	service := &v1.Service{ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "team"}}
+	clientset := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{Name: constants.XPOSER_CONFIGMAP, Namespace: "team"},
+		Data:       map[string]string{"custom": "value"},
+	})
+
+	PopulateConfigMapLocally(clientset, Listers{}, service, map[string]string{ConfigMapKey(service): "app.example.com"})
+
+	configMap, err := clientset.CoreV1().ConfigMaps("team").Get(constants.XPOSER_CONFIGMAP, meta_v1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !ownership.IsManaged(configMap.ObjectMeta) {
+		t.Errorf("PopulateConfigMapLocally() labels = %v, want the configmap labeled as managed", configMap.Labels)
+	}
+	if configMap.Data["custom"] != "value" || configMap.Data["app-team"] != "app.example.com" {
+		t.Errorf("PopulateConfigMapLocally() data = %v, want the user key kept", configMap.Data)
+	}
+}
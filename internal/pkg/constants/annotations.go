@@ -8,4 +8,14 @@ const (
 	INGRESS_NAME_TEMPLATE            = "IngressNameTemplate"
 	TLS                              = "TLS"
 	SECRET_NAME_TEMPLATE             = "TLSSecretNameTemplate"
+	CLUSTER_NAME                     = "ClusterName"
+	MANAGED_BY_LABEL                 = "app.kubernetes.io/managed-by"
+	OWNER_ANNOTATION                 = "xposer.stakater.com/owner"
+	IGNORE_DRIFT_ANNOTATION          = "xposer.stakater.com/ignore-drift"
+	STATUS_ANNOTATION                = "xposer.stakater.com/status"
+	IDENTITY_ANNOTATION              = "xposer.stakater.com/identity"
+	LAST_APPLIED_ANNOTATION          = "xposer.stakater.com/last-applied"
+	TIERS_ANNOTATION                 = "xposer.stakater.com/tiers"
+	INGRESS_CLASS_ANNOTATION         = "kubernetes.io/ingress.class"
+	ANNOTATION_PROFILES_ANNOTATION   = "xposer.stakater.com/annotation-profiles"
 )
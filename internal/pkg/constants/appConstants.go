@@ -12,9 +12,12 @@ const (
 	DOMAIN             = "Domain"
 	CERT               = "-cert"
 	RESYNC_PERIOD      = 10 * time.Second
+	CONFIG_POLL_PERIOD = 5 * time.Second
 	XPOSER_CONFIGMAP   = "xposer"
 	EXPOSE_INGRESS_URL = "exposeIngressUrl"
 	LOCALLY            = "locally"
 	GLOBALLY           = "globally"
 	EXPOSE             = "expose"
+	NO_SECRET          = "NO_SECRET"
+	CONTROLLER_NAME    = "xposer"
 )
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	osV1 "github.com/openshift/api/route/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stakater/Xposer/internal/pkg/apply"
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/ownership"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Reasons of the events recorded on services when an object with the generated name already exists
+const (
+	reasonNameConflict = "NameConflict"
+	reasonAdopted      = "Adopted"
+)
+
+// createIngress creates the generated Ingress. If an Ingress with the same name already exists, it is updated only
+// if it was generated for the service or the adoption policy allows taking it over. It returns true if the generated
+// Ingress was applied
+func (c *Controller) createIngress(service *v1.Service, ingress *v1beta1.Ingress) bool {
+	ingressClient := c.clientset.ExtensionsV1beta1().Ingresses(ingress.Namespace)
+
+	err := apply.RecordLastApplied(ingress, &ingress.ObjectMeta)
+	if err != nil {
+		logrus.Warnf("Can not record the applied Ingress: %v, with error: %v", ingress.Name, err)
+		return false
+	}
+
+	result, err := ingressClient.Create(ingress)
+	if err == nil {
+		logrus.Infof("Successfully created an Ingress with name: %v", result.Name)
+		return true
+	}
+	if !errors.IsAlreadyExists(err) {
+		logrus.Warnf("Can not create new Ingress: %v", err)
+		return false
+	}
+
+	existing, err := ingressClient.Get(ingress.Name, meta_v1.GetOptions{})
+	if err != nil {
+		logrus.Warnf("Can not get existing Ingress: %v, with error: %v", ingress.Name, err)
+		return false
+	}
+	if !c.mayAdopt(service, existing.ObjectMeta, "Ingress", ingresses.TargetsService(existing, service.Name)) {
+		return false
+	}
+
+	err = c.applyIngress(ingress)
+	if err != nil {
+		logrus.Warnf("Can not update existing Ingress: %v, with error: %v", ingress.Name, err)
+		return false
+	}
+
+	return true
+}
+
+// createRoute creates the generated Route, applying the adoption policy like createIngress
+func (c *Controller) createRoute(service *v1.Service, route *osV1.Route) bool {
+	routeClient := c.osClient.Routes(route.Namespace)
+
+	err := apply.RecordLastApplied(route, &route.ObjectMeta)
+	if err != nil {
+		logrus.Errorf("Can not record the applied Route: %v, with error: %v", route.Name, err)
+		return false
+	}
+
+	result, err := routeClient.Create(route)
+	if err == nil {
+		logrus.Infof("Successfully created a Route with name: %v", result.Name)
+		return true
+	}
+	if !errors.IsAlreadyExists(err) {
+		logrus.Errorf("Error while creating Route: %v", err)
+		return false
+	}
+
+	existing, err := routeClient.Get(route.Name, meta_v1.GetOptions{})
+	if err != nil {
+		logrus.Errorf("Can not get existing Route: %v, with error: %v", route.Name, err)
+		return false
+	}
+	targetsService := existing.Spec.To.Kind == "Service" && existing.Spec.To.Name == service.Name
+	if !c.mayAdopt(service, existing.ObjectMeta, "Route", targetsService) {
+		return false
+	}
+
+	err = c.applyRoute(route)
+	if err != nil {
+		logrus.Errorf("Can not update existing Route: %v, with error: %v", route.Name, err)
+		return false
+	}
+
+	return true
+}
+
+// mayAdopt decides whether an existing object with the generated name may be replaced by the generated one. Objects
+// generated for the service are always updated, objects generated for another service never are, as both services
+// would keep overwriting each other. Other objects are handled according to the adoption policy
+func (c *Controller) mayAdopt(service *v1.Service, existing meta_v1.ObjectMeta, kind string, targetsService bool) bool {
+	ownerNamespace, ownerName, owned := ownership.Owner(existing)
+	if owned && ownerNamespace == service.Namespace && ownerName == service.Name {
+		return true
+	}
+	if owned {
+		c.recordConflict(service, existing, kind, "it is generated for service "+ownerNamespace+"/"+ownerName)
+		return false
+	}
+
+	switch c.getConfig().AdoptionPolicy {
+	case config.AdoptionPolicyOverwrite:
+	case config.AdoptionPolicyAdopt:
+		if !targetsService {
+			c.recordConflict(service, existing, kind, "it does not route to the service")
+			return false
+		}
+	default:
+		c.recordConflict(service, existing, kind, "it was not generated by Xposer")
+		return false
+	}
+
+	logrus.Infof("Adopting %v: %v in namespace: %v for service: %v", kind, existing.Name, existing.Namespace, service.Name)
+	c.recorder.Eventf(service, v1.EventTypeNormal, reasonAdopted, "Adopted existing %v %v", kind, existing.Name)
+	return true
+}
+
+func (c *Controller) recordConflict(service *v1.Service, existing meta_v1.ObjectMeta, kind string, reason string) {
+	logrus.Warnf("Service: %v in namespace: %v is not exposed, %v: %v already exists and %v",
+		service.Name, service.Namespace, kind, existing.Name, reason)
+	c.recorder.Eventf(service, v1.EventTypeWarning, reasonNameConflict, "Service is not exposed, %v %v already exists and %v",
+		kind, existing.Name, reason)
+}
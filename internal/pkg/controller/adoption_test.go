@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/ownership"
+	"github.com/stakater/Xposer/internal/pkg/scope"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCreateIngress(t *testing.T) {
+	service := &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "app",
+			Namespace: "team",
+			Labels:    map[string]string{constants.EXPOSE: "true"},
+		},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+	}
+
+	tests := []struct {
+		name        string
+		policy      string
+		owner       string
+		backend     string
+		wantAdopted bool
+	}{
+		{
+			name:        "ingress of the service should be updated with any policy",
+			policy:      config.AdoptionPolicyRefuse,
+			owner:       "app",
+			backend:     "app",
+			wantAdopted: true,
+		},
+		{
+			name:    "ingress of another service should never be updated",
+			policy:  config.AdoptionPolicyOverwrite,
+			owner:   "other",
+			backend: "other",
+		},
+		{
+			name:    "hand-made ingress should be refused",
+			policy:  config.AdoptionPolicyRefuse,
+			backend: "app",
+		},
+		{
+			name:        "hand-made ingress routing to the service should be adopted",
+			policy:      config.AdoptionPolicyAdopt,
+			backend:     "app",
+			wantAdopted: true,
+		},
+		{
+			name:    "hand-made ingress routing to another service should not be adopted",
+			policy:  config.AdoptionPolicyAdopt,
+			backend: "other",
+		},
+		{
+			name:        "hand-made ingress should be overwritten",
+			policy:      config.AdoptionPolicyOverwrite,
+			backend:     "other",
+			wantAdopted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.DefaultConfiguration()
+			conf.Domain = "example.com"
+			conf.AdoptionPolicy = tt.policy
+
+			ingressInfo, err := ingresses.CreateIngressInfo(service, nil, conf)
+			if err != nil {
+				t.Fatalf("CreateIngressInfo() error = %v", err)
+			}
+			existing := &v1beta1.Ingress{
+				ObjectMeta: meta_v1.ObjectMeta{Name: ingressInfo.IngressName, Namespace: service.Namespace},
+				Spec: v1beta1.IngressSpec{
+					Backend: &v1beta1.IngressBackend{ServiceName: tt.backend},
+				},
+			}
+			if tt.owner != "" {
+				ownership.Mark(&existing.ObjectMeta, service.Namespace, tt.owner)
+			}
+
+			clientset := newPatchingClientset(existing)
+			c := NewController(clientset, nil, conf, constants.KUBERNETES, scope.Scope{}, constants.RESYNC_PERIOD)
+
+			c.createIngress(service, ingresses.CreateWithTLSFromIngressInfo(ingressInfo))
+
+			got, err := clientset.ExtensionsV1beta1().Ingresses(service.Namespace).Get(ingressInfo.IngressName, meta_v1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			_, owner, _ := ownership.Owner(got.ObjectMeta)
+			if adopted := owner == service.Name && len(got.Spec.Rules) > 0; adopted != tt.wantAdopted {
+				t.Errorf("createIngress() adopted = %v, want %v", adopted, tt.wantAdopted)
+			}
+		})
+	}
+}
+
+func TestUpdateExposureDoesNotPatchForeignIngress(t *testing.T) {
+	service := &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "app",
+			Namespace: "team",
+			Labels:    map[string]string{constants.EXPOSE: "true"},
+		},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+	}
+
+	tests := []struct {
+		name  string
+		owner string
+	}{
+		{
+			name: "hand-made ingress should not be patched",
+		},
+		{
+			name:  "ingress of another service should not be patched",
+			owner: "other",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.DefaultConfiguration()
+			conf.Domain = "example.com"
+			conf.AdoptionPolicy = config.AdoptionPolicyRefuse
+
+			existing := &v1beta1.Ingress{
+				ObjectMeta: meta_v1.ObjectMeta{Name: service.Name, Namespace: service.Namespace},
+				Spec: v1beta1.IngressSpec{
+					Backend: &v1beta1.IngressBackend{ServiceName: "other"},
+				},
+			}
+			if tt.owner != "" {
+				ownership.Mark(&existing.ObjectMeta, service.Namespace, tt.owner)
+			}
+
+			clientset := newPatchingClientset(service, existing)
+			c := NewController(clientset, nil, conf, constants.KUBERNETES, scope.Scope{}, constants.RESYNC_PERIOD)
+
+			// A config reload updates the exposure of every service
+			c.updateExposure(service, service)
+
+			for _, action := range clientset.Actions() {
+				if action.GetVerb() == "patch" && action.GetResource().Resource == "ingresses" {
+					t.Errorf("updateExposure() patched Ingress %v", action.(k8stesting.PatchAction).GetName())
+				}
+			}
+			got, err := clientset.ExtensionsV1beta1().Ingresses(service.Namespace).Get(service.Name, meta_v1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got.Spec.Backend == nil || got.Spec.Backend.ServiceName != "other" || len(got.Spec.Rules) != 0 {
+				t.Errorf("updateExposure() changed the foreign Ingress: %+v", got.Spec)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"errors"
+
+	osV1 "github.com/openshift/api/route/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stakater/Xposer/internal/pkg/apply"
+	"k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// errNotAdopted is returned when the existing object with the generated name may not be replaced, see mayAdopt
+var errNotAdopted = errors.New("the existing object may not be adopted")
+
+// applyIngress patches the existing Ingress with the desired one, keeping the fields set by others. Nothing is
+// written if the Ingress is up to date, and the patch is retried with the latest Ingress if it changed in between.
+// A NotFound error is returned if the Ingress does not exist
+func (c *Controller) applyIngress(desired *v1beta1.Ingress) error {
+	return c.applyIngressIf(desired, nil)
+}
+
+// applyIngressIf patches the existing Ingress like applyIngress, if mayApply allows it for the current Ingress. It
+// returns errNotAdopted otherwise, without writing anything
+func (c *Controller) applyIngressIf(desired *v1beta1.Ingress, mayApply func(current *v1beta1.Ingress) bool) error {
+	ingressClient := c.clientset.ExtensionsV1beta1().Ingresses(desired.Namespace)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := ingressClient.Get(desired.Name, meta_v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if mayApply != nil && !mayApply(current) {
+			return errNotAdopted
+		}
+
+		patch, err := apply.IngressPatch(desired, current)
+		if err != nil {
+			return err
+		}
+		if patch == nil {
+			logrus.Debugf("Ingress: %v in namespace: %v is up to date", desired.Name, desired.Namespace)
+			return nil
+		}
+
+		_, err = ingressClient.Patch(desired.Name, types.StrategicMergePatchType, patch)
+		if err == nil {
+			logrus.Infof("Successfully updated an Ingress with name: %v", desired.Name)
+		}
+		return err
+	})
+}
+
+// applyRoute patches the existing Route with the desired one, see applyIngress
+func (c *Controller) applyRoute(desired *osV1.Route) error {
+	routeClient := c.osClient.Routes(desired.Namespace)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := routeClient.Get(desired.Name, meta_v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		patch, err := apply.RoutePatch(desired, current)
+		if err != nil {
+			return err
+		}
+		if patch == nil {
+			logrus.Debugf("Route: %v in namespace: %v is up to date", desired.Name, desired.Namespace)
+			return nil
+		}
+
+		_, err = routeClient.Patch(desired.Name, types.MergePatchType, patch)
+		if err == nil {
+			logrus.Infof("Successfully updated a Route with name: %v", desired.Name)
+		}
+		return err
+	})
+}
@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stakater/Xposer/internal/pkg/apply"
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/scope"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newPatchingClientset returns a fake clientset which, unlike the one of NewSimpleClientset, applies the strategic
+// merge patches of Ingresses
+func newPatchingClientset(objects ...runtime.Object) *fake.Clientset {
+	tracker := k8stesting.NewObjectTracker(scheme.Scheme, scheme.Codecs.UniversalDecoder())
+	for _, obj := range objects {
+		if err := tracker.Add(obj); err != nil {
+			panic(err)
+		}
+	}
+
+	clientset := &fake.Clientset{}
+	clientset.AddReactor("patch", "ingresses", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(k8stesting.PatchAction)
+		current, err := tracker.Get(action.GetResource(), action.GetNamespace(), patchAction.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		currentJSON, err := json.Marshal(current)
+		if err != nil {
+			return true, nil, err
+		}
+		patchedJSON, err := strategicpatch.StrategicMergePatch(currentJSON, patchAction.GetPatch(), &v1beta1.Ingress{})
+		if err != nil {
+			return true, nil, err
+		}
+		patched := &v1beta1.Ingress{}
+		if err = json.Unmarshal(patchedJSON, patched); err != nil {
+			return true, nil, err
+		}
+		return true, patched, tracker.Update(action.GetResource(), patched, action.GetNamespace())
+	})
+	clientset.AddReactor("*", "*", k8stesting.ObjectReaction(tracker))
+	clientset.AddWatchReactor("*", k8stesting.DefaultWatchReactor(watch.NewFake(), nil))
+
+	return clientset
+}
+
+func TestApplyIngress(t *testing.T) {
+	newIngress := func(annotations map[string]string) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "team", Annotations: annotations},
+			Spec: v1beta1.IngressSpec{
+				Rules: []v1beta1.IngressRule{{Host: "app.example.com"}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		current     map[string]string
+		desired     map[string]string
+		conflicts   int
+		wantPatches int
+		want        map[string]string
+	}{
+		{
+			name:    "up to date ingress should not be patched",
+			current: map[string]string{"a": "1"},
+			desired: map[string]string{"a": "1"},
+			want:    map[string]string{"a": "1"},
+		},
+		{
+			name:        "annotations of others should be kept",
+			current:     map[string]string{"a": "1", "cert-manager.io/issuer": "letsencrypt"},
+			desired:     map[string]string{"a": "2"},
+			wantPatches: 1,
+			want:        map[string]string{"a": "2", "cert-manager.io/issuer": "letsencrypt"},
+		},
+		{
+			name:        "conflicting patch should be retried",
+			current:     map[string]string{"a": "1"},
+			desired:     map[string]string{"a": "2"},
+			conflicts:   1,
+			wantPatches: 2,
+			want:        map[string]string{"a": "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The current Ingress was created by Xposer with the desired annotations, the others were added later
+			current := newIngress(map[string]string{})
+			for key, value := range tt.desired {
+				current.Annotations[key] = value
+			}
+			if err := apply.RecordLastApplied(current, &current.ObjectMeta); err != nil {
+				t.Fatalf("RecordLastApplied() error = %v", err)
+			}
+			for key, value := range tt.current {
+				current.Annotations[key] = value
+			}
+
+			clientset := newPatchingClientset(current)
+			conflicts := tt.conflicts
+			clientset.PrependReactor("patch", "ingresses", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if conflicts == 0 {
+					return false, nil, nil
+				}
+				conflicts--
+				return true, nil, errors.NewConflict(action.GetResource().GroupResource(), "app", nil)
+			})
+			c := NewController(clientset, nil, config.DefaultConfiguration(), constants.KUBERNETES, scope.Scope{}, constants.RESYNC_PERIOD)
+
+			if err := c.applyIngress(newIngress(tt.desired)); err != nil {
+				t.Fatalf("applyIngress() error = %v", err)
+			}
+
+			patches := 0
+			for _, action := range clientset.Actions() {
+				if action.GetVerb() == "patch" {
+					patches++
+				}
+			}
+			if patches != tt.wantPatches {
+				t.Errorf("applyIngress() patches = %v, want %v", patches, tt.wantPatches)
+			}
+
+			got, err := clientset.ExtensionsV1beta1().Ingresses("team").Get("app", meta_v1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			delete(got.Annotations, constants.LAST_APPLIED_ANNOTATION)
+			if len(got.Annotations) != len(tt.want) {
+				t.Errorf("applyIngress() annotations = %v, want %v", got.Annotations, tt.want)
+			}
+			for key, value := range tt.want {
+				if got.Annotations[key] != value {
+					t.Errorf("applyIngress() annotations = %v, want %v", got.Annotations, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -2,23 +2,34 @@ package controller
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
-	"github.com/fatih/structs"
 	routeClient "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
 	"github.com/sirupsen/logrus"
 	config "github.com/stakater/Xposer/internal/pkg/config"
 	"github.com/stakater/Xposer/internal/pkg/configmaps"
 	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/dryrun"
 	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/metrics"
 	"github.com/stakater/Xposer/internal/pkg/routes"
+	"github.com/stakater/Xposer/internal/pkg/scope"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	extensionslisters "k8s.io/client-go/listers/extensions/v1beta1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 )
 
@@ -39,23 +50,73 @@ type Controller struct {
 	indexer     cache.Indexer
 	queue       workqueue.RateLimitingInterface
 	informer    cache.Controller
-	config      config.Configuration
+	// servicesSynced tells whether the services were listed, before which services missing in the indexer may exist
+	servicesSynced cache.InformerSynced
+	config         config.Configuration
+	selection      config.Selection
+	configLock     sync.RWMutex
+	recorder       record.EventRecorder
+	// watchSelector is the label selector the services are watched with, it is only read at startup
+	watchSelector string
+
+	// informerFactory holds the shared informers, whose caches serve the reads of the event handlers
+	informerFactory informers.SharedInformerFactory
+	listers         configmaps.Listers
+	ingressLister   extensionslisters.IngressLister
+
+	// driftIndexer and driftInformer hold the Ingresses or Routes, they are nil without a route client
+	driftIndexer  cache.Indexer
+	driftInformer cache.SharedIndexInformer
+
+	// scope holds the watched namespaces. Informers are restricted to namespace if it is the only one, and watch all
+	// namespaces otherwise, whose objects are filtered with scope
+	scope scope.Scope
+
+	// reportedChanges holds the dry run changes which were reported already. They never reach the informers, so every
+	// resync would repeat them otherwise
+	reportedChanges map[dryrun.Change]bool
+	reportedLock    sync.Mutex
 }
 
 // NewController A Constructor for the Controller to initialize the controller
-func NewController(clientset kubernetes.Interface, osClient *routeClient.RouteV1Client, conf config.Configuration, clusterType string, namespace string) *Controller {
+func NewController(clientset kubernetes.Interface, osClient *routeClient.RouteV1Client, conf config.Configuration, clusterType string, watched scope.Scope, resyncPeriod time.Duration) *Controller {
+	namespace := watched.Namespace()
 	controller := &Controller{
 		clientset:   clientset,
 		osClient:    osClient,
 		config:      conf,
+		selection:   newSelection(conf),
 		clusterType: clusterType,
 		namespace:   namespace,
+		scope:       watched,
+
+		reportedChanges: make(map[dryrun.Change]bool),
 	}
+	controller.listers.Scope = watched
+
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	controller.recorder = eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: constants.CONTROLLER_NAME})
 
 	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
-	listWatcher := cache.NewListWatchFromClient(clientset.CoreV1().RESTClient(), constants.SERVICES, namespace, fields.Everything())
 
-	indexer, informer := cache.NewIndexerInformer(listWatcher, &v1.Service{}, constants.RESYNC_PERIOD, cache.ResourceEventHandlerFuncs{
+	// Only services selected by the expose selector are listed and watched, all of them if services may opt in with the
+	// expose annotation. A service whose labels do not match anymore leaves the selection, and the watch reports it as
+	// deleted
+	selector := controller.selection.ServiceSelector()
+	controller.watchSelector = selector
+	listWatcher := &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (k8sruntime.Object, error) {
+			options.LabelSelector = selector
+			return clientset.CoreV1().Services(namespace).List(options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = selector
+			return clientset.CoreV1().Services(namespace).Watch(options)
+		},
+	}
+
+	indexer, informer := cache.NewIndexerInformer(listWatcher, &v1.Service{}, resyncPeriod, cache.ResourceEventHandlerFuncs{
 		AddFunc:    controller.Add,    //function that is called when the object is created
 		UpdateFunc: controller.Update, //function that is called when the object is updated
 		DeleteFunc: controller.Delete, //function that is called when the object is deleted
@@ -63,11 +124,17 @@ func NewController(clientset kubernetes.Interface, osClient *routeClient.RouteV1
 
 	controller.indexer = indexer
 	controller.informer = informer
+	controller.servicesSynced = informer.HasSynced
 	controller.queue = queue
+	controller.informerFactory = informers.NewFilteredSharedInformerFactory(clientset, resyncPeriod, namespace, nil)
+	controller.driftInformer = controller.newDriftInformer()
+	if controller.driftInformer != nil {
+		controller.driftIndexer = controller.driftInformer.GetIndexer()
+	}
 	return controller
 }
 
-//Add function to add a 'create' event to the queue
+// Add function to add a 'create' event to the queue
 func (c *Controller) Add(obj interface{}) {
 	key, err := cache.MetaNamespaceKeyFunc(obj)
 	var event Event
@@ -80,7 +147,7 @@ func (c *Controller) Add(obj interface{}) {
 	}
 }
 
-//Update function to add an 'update' event to the queue
+// Update function to add an 'update' event to the queue
 func (c *Controller) Update(oldObj interface{}, newObj interface{}) {
 	key, err := cache.MetaNamespaceKeyFunc(newObj)
 	var event Event
@@ -94,8 +161,11 @@ func (c *Controller) Update(oldObj interface{}, newObj interface{}) {
 	}
 }
 
-//Delete function to add a 'delete' event to the queue
+// Delete function to add a 'delete' event to the queue
 func (c *Controller) Delete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
 	key, err := cache.MetaNamespaceKeyFunc(obj)
 	var event Event
 
@@ -107,7 +177,91 @@ func (c *Controller) Delete(obj interface{}) {
 	}
 }
 
-//Run function for controller which handles the queue
+// UpdateConfig atomically replaces the configuration of the controller and re-enqueues all watched services so
+// that the new configuration is applied to them, or their exposure removed if they are not selected anymore. A
+// configuration which changes the selector the services are watched with is rejected, as the services it selects
+// would not be seen until Xposer is restarted
+func (c *Controller) UpdateConfig(conf config.Configuration) error {
+	selection := newSelection(conf)
+	if selection.ServiceSelector() != c.watchSelector {
+		return fmt.Errorf("the services are watched with the selector %q, Xposer must be restarted to watch them with %q",
+			c.watchSelector, selection.ServiceSelector())
+	}
+
+	c.configLock.Lock()
+	c.config = conf
+	c.selection = selection
+	c.configLock.Unlock()
+
+	for _, obj := range c.indexer.List() {
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err == nil {
+			c.queue.Add(Event{
+				key:       key,
+				eventType: "reload",
+				newObject: obj,
+			})
+		}
+	}
+
+	return nil
+}
+
+// getConfig returns the configuration currently in use
+func (c *Controller) getConfig() config.Configuration {
+	c.configLock.RLock()
+	defer c.configLock.RUnlock()
+
+	return c.config
+}
+
+// exposed tells whether the service is in a watched namespace and selected to be exposed by the configuration
+// currently in use. Its namespace is only read with namespace selectors, and a namespace which can not be read is not
+// selected
+func (c *Controller) exposed(service *v1.Service) bool {
+	c.configLock.RLock()
+	selection := c.selection
+	c.configLock.RUnlock()
+
+	var namespace *v1.Namespace
+	if selection.NeedsNamespace() || c.scope.NeedsNamespace() {
+		namespace = c.readNamespace(service.Namespace)
+	}
+
+	return c.scope.Includes(service.Namespace, namespace) && selection.Exposes(service, namespace)
+}
+
+// inScope tells whether the named namespace is watched
+func (c *Controller) inScope(name string) bool {
+	if !c.scope.NeedsNamespace() {
+		return c.scope.Includes(name, nil)
+	}
+
+	return c.scope.Includes(name, c.readNamespace(name))
+}
+
+// readNamespace returns a namespace, or nil if it can not be read
+func (c *Controller) readNamespace(name string) *v1.Namespace {
+	namespace, err := c.getNamespace(name)
+	if err != nil {
+		logrus.Warnf("Can not fetch namespace: %v, its services are not exposed: %v", name, err)
+		return nil
+	}
+
+	return namespace
+}
+
+// newSelection compiles the selectors of a configuration, which exposes no service if they do not parse
+func newSelection(conf config.Configuration) config.Selection {
+	selection, err := config.NewSelection(conf)
+	if err != nil {
+		logrus.Errorf("No service is exposed, the selectors of the configuration are invalid: %v", err)
+	}
+
+	return selection
+}
+
+// Run function for controller which handles the queue
 func (c *Controller) Run(threadiness int, stopCh chan struct{}) {
 	defer runtime.HandleCrash()
 
@@ -115,9 +269,10 @@ func (c *Controller) Run(threadiness int, stopCh chan struct{}) {
 	defer c.queue.ShutDown()
 
 	go c.informer.Run(stopCh)
+	cacheSyncs := append([]cache.InformerSynced{c.informer.HasSynced}, c.startInformers(stopCh)...)
 
 	// Wait for all involved caches to be synced, before processing items from the queue is started
-	if !cache.WaitForCacheSync(stopCh, c.informer.HasSynced) {
+	if !cache.WaitForCacheSync(stopCh, cacheSyncs...) {
 		runtime.HandleError(fmt.Errorf("Timed out waiting for caches to sync"))
 		return
 	}
@@ -152,7 +307,7 @@ func (c *Controller) processNextItem() bool {
 	return true
 }
 
-//takeAction, the main function which will be handling the controller business logic
+// takeAction, the main function which will be handling the controller business logic
 func (c *Controller) takeAction(event Event) error {
 	// process events based on its type
 	switch event.eventType {
@@ -164,6 +319,12 @@ func (c *Controller) takeAction(event Event) error {
 
 	case "delete":
 		c.serviceDeleted(event.newObject) //Incase of deleted, the obj object is nil
+
+	case "reload":
+		c.configReloaded(event.newObject)
+
+	case "drift":
+		c.driftDetected(event.newObject)
 	}
 
 	return nil
@@ -172,50 +333,16 @@ func (c *Controller) takeAction(event Event) error {
 func (c *Controller) serviceCreated(obj interface{}) {
 	newServiceObject := obj.(*v1.Service)
 
-	// Label for wether to create an ingress for this service or not
-	if newServiceObject.ObjectMeta.Labels[constants.EXPOSE] == "true" {
-		logrus.Info("Service create event for the following service: %v", newServiceObject.Name)
-		ingressInfo := ingresses.CreateIngressInfo(newServiceObject, c.config)
-
-		if c.clusterType == constants.KUBERNETES {
-			ingress := ingresses.CreateFromIngressInfo(ingressInfo)
-			// Adds TLS for cert-manager if specified via annotations
-			if ingressInfo.AddTLS == true {
-				if ingressInfo.SecretName != "NO_SECRET" {
-					logrus.Info("Service contain TLS annotation,Generating from template")
-					ingresses.AddTLSInfoTemplate(ingress, ingressInfo.SecretName, ingressInfo.IngressHost)
-				} else {
-					logrus.Info("Service contain TLS annotation, so automatically generating a TLS certificate via certmanager")
-					ingresses.AddTLSInfo(ingress, ingressInfo.IngressName, ingressInfo.IngressHost)
-				}
-			}
-
-			result, err := c.clientset.ExtensionsV1beta1().Ingresses(ingressInfo.Namespace).Create(ingress)
-			if err != nil {
-				logrus.Warnf("Can not create new Ingress: %v", err)
-			} else {
-				logrus.Infof("Successfully created an Ingress with name: %v", result.Name)
-			}
-
-			if ingressInfo.ForwardAnnotationsMap[constants.EXPOSE_INGRESS_URL] == constants.GLOBALLY {
-				configmaps.PopulateConfigMapGlobally(c.clientset, newServiceObject, ingressInfo.IngressHost)
-			} else if ingressInfo.ForwardAnnotationsMap[constants.EXPOSE_INGRESS_URL] == constants.LOCALLY {
-				configmaps.PopulateConfigMapLocally(c.clientset, newServiceObject, ingressInfo.IngressHost)
-			}
+	// Selectors for wether to create an ingress for this service or not
+	if c.exposed(newServiceObject) {
+		logrus.Infof("Service create event for the following service: %v", newServiceObject.Name)
+		ingressInfos, err := c.createIngressInfos(newServiceObject, c.getConfig())
+		if err != nil {
+			c.recordRenderError(newServiceObject, err)
+			return
 		}
 
-		if c.clusterType == constants.OPENSHIFT {
-			route := routes.Create(ingressInfo.IngressName, ingressInfo.Namespace, ingressInfo.ForwardAnnotationsMap,
-				ingressInfo.IngressHost, ingressInfo.IngressPath, ingressInfo.ServiceName, ingressInfo.ServicePort)
-
-			result, err := c.osClient.Routes(ingressInfo.Namespace).Create(route)
-
-			if err != nil {
-				logrus.Errorf("Error while creating Route: %v", err)
-			} else {
-				logrus.Infof("Successfully created a Route with name: %v", result.Name)
-			}
-		}
+		c.applyExposures(newServiceObject, ingressInfos, false)
 	}
 }
 
@@ -224,58 +351,17 @@ func (c *Controller) serviceUpdated(oldObj interface{}, newObj interface{}) {
 	oldServiceObject := oldObj.(*v1.Service)
 
 	if oldServiceObject != newServiceObject {
-		if newServiceObject.ObjectMeta.Labels[constants.EXPOSE] == "true" && oldServiceObject.ObjectMeta.Labels[constants.EXPOSE] == "true" {
-			oldIngressConfig := structs.Map(c.config)
-			oldIngressConfig = config.ReplaceDefaultConfigWithProvidedServiceConfig(oldIngressConfig, oldServiceObject)
-
-			newIngressConfig := structs.Map(c.config)
-			newIngressConfig = config.ReplaceDefaultConfigWithProvidedServiceConfig(newIngressConfig, newServiceObject)
-
-			if oldIngressConfig[constants.INGRESS_NAME_TEMPLATE].(string) != newIngressConfig[constants.INGRESS_NAME_TEMPLATE].(string) {
-				logrus.Info("Old service's Ingress Name template is different from new Service's Ingress Name Template. So deleting and re-creating Ingress in this case")
-				c.serviceDeleted(oldObj)
-				c.serviceCreated(newObj)
-			} else {
-				ingressInfo := ingresses.CreateIngressInfo(newServiceObject, c.config)
-				ingress := ingresses.CreateFromIngressInfo(ingressInfo)
-
-				if ingressInfo.AddTLS == true {
-					if ingressInfo.SecretName != "NO_SECRET" {
-						logrus.Info("Service contain TLS annotation,Generating from template")
-						ingresses.AddTLSInfoTemplate(ingress, ingressInfo.SecretName, ingressInfo.IngressHost)
-					} else {
-						ingresses.AddTLSInfo(ingress, ingressInfo.IngressName, ingressInfo.IngressHost)
-						logrus.Info("Added TLS Info for certmanager")
-					}
-				}
-
-				result, err := c.clientset.ExtensionsV1beta1().Ingresses(ingressInfo.Namespace).Update(ingress)
-				if err != nil {
-					logrus.Errorf("Error while Updating Ingress: %v", err)
-				} else {
-					logrus.Infof("Successfully updated an Ingress with name: %v, for service: %v", result.Name, result.Spec.Backend.ServiceName)
-				}
-
-				// Updating exposed services URLs
-				if ingressInfo.ForwardAnnotationsMap[constants.EXPOSE_INGRESS_URL] == constants.GLOBALLY {
-					configmaps.DeleteFromConfigMapGlobally(c.clientset, oldServiceObject)
-					configmaps.PopulateConfigMapGlobally(c.clientset, newServiceObject, ingressInfo.IngressHost)
-				} else if ingressInfo.ForwardAnnotationsMap[constants.EXPOSE_INGRESS_URL] == constants.LOCALLY {
-					configmaps.DeleteFromConfigMapLocally(c.clientset, oldServiceObject)
-					configmaps.PopulateConfigMapLocally(c.clientset, newServiceObject, ingressInfo.IngressHost)
-				}
-			}
-		} else {
-			if newServiceObject.ObjectMeta.Labels[constants.EXPOSE] == "false" {
-				c.serviceDeleted(oldObj)
-			}
-
-			if newServiceObject.ObjectMeta.Labels[constants.EXPOSE] == "true" {
-				c.serviceCreated(newObj)
-			}
+		wasExposed, isExposed := c.exposed(oldServiceObject), c.exposed(newServiceObject)
+		if wasExposed && isExposed {
+			// A changed Ingress name is handled by the migration in updateExposure
+			c.updateExposure(oldServiceObject, newServiceObject)
+		} else if wasExposed {
+			c.serviceDeleted(oldObj)
+		} else if isExposed {
+			c.serviceCreated(newObj)
 		}
-	} else {
-		ingressList, err := c.clientset.ExtensionsV1beta1().Ingresses(oldServiceObject.Namespace).List(meta_v1.ListOptions{})
+	} else if c.exposed(newServiceObject) {
+		ingressList, err := c.listIngresses(oldServiceObject.Namespace)
 		if err != nil {
 			logrus.Errorf("Can not fetch Ingresses in the following namespace: %v, with the following error: %v", oldServiceObject.Namespace, err)
 		}
@@ -286,36 +372,159 @@ func (c *Controller) serviceUpdated(oldObj interface{}, newObj interface{}) {
 	}
 }
 
-func (c *Controller) serviceDeleted(deletedServiceObject interface{}) {
-	serviceToDelete := deletedServiceObject.(*v1.Service)
+// configReloaded applies a reloaded configuration to a service, whose exposure is removed if it is not selected
+// anymore
+func (c *Controller) configReloaded(obj interface{}) {
+	serviceObject := obj.(*v1.Service)
+	if !c.exposed(serviceObject) {
+		c.serviceDeleted(serviceObject)
+		return
+	}
+
+	logrus.Infof("Applying reloaded configuration to service: %v", serviceObject.Name)
+	c.updateExposure(serviceObject, serviceObject)
+}
 
-	// Only delete ingress if the service had expose = true label
-	if serviceToDelete.ObjectMeta.Labels["expose"] == "true" {
-		logrus.Info("Service delete event for the following service: %v", serviceToDelete.Name)
+// updateExposure updates the Ingresses, or Routes, and the exposed URLs of a service which stays exposed. If the
+// identity of a generated object changed, e.g. its name, the new object is created before the old one is deleted
+func (c *Controller) updateExposure(oldServiceObject *v1.Service, newServiceObject *v1.Service) {
+	ingressInfos, err := c.createIngressInfos(newServiceObject, c.getConfig())
+	if err != nil {
+		c.recordRenderError(newServiceObject, err)
+		return
+	}
 
-		ingressList, err := c.clientset.ExtensionsV1beta1().Ingresses(serviceToDelete.Namespace).List(meta_v1.ListOptions{})
-		if err != nil {
-			logrus.Errorf("Can not fetch Ingresses in the following namespace: %v, with the following error: %v", serviceToDelete.Namespace, err)
+	c.applyExposures(newServiceObject, ingressInfos, true)
+}
+
+// applyExposures creates or updates the Ingress, or Route, of every tier a service is exposed on, and publishes their
+// URLs. The objects generated before for the service, e.g. on a tier it is not exposed on anymore, are only deleted
+// once every object was applied, so that the service stays exposed during the migration
+func (c *Controller) applyExposures(service *v1.Service, ingressInfos []ingresses.IngressInfo, update bool) {
+	free := c.checkHostClaims(service, ingressInfos)
+	applied := len(free) == len(ingressInfos)
+	desired := []meta_v1.ObjectMeta{}
+	published := []ingresses.IngressInfo{}
+
+	for _, ingressInfo := range free {
+		if c.clusterType == constants.OPENSHIFT {
+			route := routes.Create(ingressInfo.IngressName, ingressInfo.Namespace, ingressInfo.ForwardAnnotationsMap,
+				ingressInfo.IngressHost, ingressInfo.IngressPath, ingressInfo.ServiceName, ingressInfo.ServicePort)
+			if c.createRoute(service, route) {
+				desired = append(desired, route.ObjectMeta)
+				published = append(published, ingressInfo)
+			} else {
+				applied = false
+			}
+			continue
 		}
 
-		ingressToRemove := ingresses.GetFromListMatchingGivenServiceName(ingressList, serviceToDelete.Name)
-		err = c.clientset.ExtensionsV1beta1().Ingresses(serviceToDelete.Namespace).Delete(ingressToRemove.ObjectMeta.Name, &meta_v1.DeleteOptions{})
-		if err != nil {
-			logrus.Warnf("Ingress not deleted with name: %v", ingressToRemove.ObjectMeta.Name)
+		ingress := ingresses.CreateWithTLSFromIngressInfo(ingressInfo)
+		if c.applyOrCreateIngress(service, ingress, update) {
+			desired = append(desired, ingress.ObjectMeta)
+			published = append(published, ingressInfo)
 		} else {
-			logrus.Infof("Ingress Deleted with name: %v", ingressToRemove.ObjectMeta.Name)
+			applied = false
 		}
+	}
+	if applied {
+		c.migrate(service, desired)
+	}
+
+	if c.clusterType == constants.KUBERNETES {
+		c.publishURLs(service, published)
+	}
+}
+
+// applyOrCreateIngress patches the Ingress of a service which stays exposed, or creates it, and returns true if the
+// Ingress is as desired. An existing Ingress which was not generated for the service is only patched if the adoption
+// policy allows it, like by createIngress
+func (c *Controller) applyOrCreateIngress(service *v1.Service, ingress *v1beta1.Ingress, update bool) bool {
+	if !update {
+		return c.createIngress(service, ingress)
+	}
+
+	err := c.applyIngressIf(ingress, func(current *v1beta1.Ingress) bool {
+		return c.mayAdopt(service, current.ObjectMeta, "Ingress", ingresses.TargetsService(current, service.Name))
+	})
+	if errors.IsNotFound(err) {
+		return c.createIngress(service, ingress)
+	} else if err == errNotAdopted {
+		return false
+	} else if err != nil {
+		logrus.Errorf("Error while Updating Ingress: %v", err)
+		return false
+	}
+
+	return true
+}
+
+// publishURLs writes the URLs of a service to the xposer configmaps, as asked by its exposeIngressUrl control key.
+// The key of a service does not change so populating overwrites its old URLs
+func (c *Controller) publishURLs(service *v1.Service, ingressInfos []ingresses.IngressInfo) {
+	if len(ingressInfos) == 0 {
+		return
+	}
+
+	urls := make(map[string]string)
+	for _, ingressInfo := range ingressInfos {
+		urls[configmaps.ConfigMapTierKey(service, ingressInfo.Tier)] = ingressInfo.IngressHost
+	}
+
+	switch ingresses.GetExposeIngressURL(service) {
+	case constants.GLOBALLY:
+		configmaps.PopulateConfigMapGlobally(c.clientset, c.listers, service, urls)
+	case constants.LOCALLY:
+		configmaps.PopulateConfigMapLocally(c.clientset, c.listers, service, urls)
+	}
+}
 
-		// Updating xposer config map if it exists
-		ingressInfo := ingresses.CreateIngressInfo(serviceToDelete, c.config)
+// serviceDeleted removes the exposure of a service which was deleted or is not exposed anymore. A deleted service
+// may not have been exposed, e.g. when all services are watched for the expose annotation, so only the objects
+// generated for it are deleted, and nothing is done if there are none and it does not opt in to be exposed
+func (c *Controller) serviceDeleted(deletedServiceObject interface{}) {
+	serviceToDelete := deletedServiceObject.(*v1.Service)
+	kind := c.generatedKind()
 
-		if ingressInfo.ForwardAnnotationsMap[constants.EXPOSE_INGRESS_URL] == constants.GLOBALLY {
-			configmaps.DeleteFromConfigMapGlobally(c.clientset, serviceToDelete)
-		} else if ingressInfo.ForwardAnnotationsMap[constants.EXPOSE_INGRESS_URL] == constants.LOCALLY {
+	generated, err := c.ownedGenerated(serviceToDelete)
+	if err != nil {
+		logrus.Errorf("Can not list the %vs generated in namespace: %v, with error: %v", kind, serviceToDelete.Namespace, err)
+	} else if len(generated) == 0 && !c.exposed(serviceToDelete) {
+		return
+	}
 
-			configmaps.DeleteFromConfigMapLocally(c.clientset, serviceToDelete)
+	logrus.Infof("Service delete event for the following service: %v", serviceToDelete.Name)
+	metrics.HostConflicts.DeleteLabelValues(serviceToDelete.Namespace, serviceToDelete.Name)
+
+	for _, objectMeta := range generated {
+		err = c.deleteGenerated(objectMeta)
+		if err != nil && !errors.IsNotFound(err) {
+			logrus.Warnf("%v not deleted with name: %v, with error: %v", kind, objectMeta.Name, err)
+		} else {
+			logrus.Infof("%v Deleted with name: %v", kind, objectMeta.Name)
 		}
 	}
+
+	// Updating xposer config map if it exists, this must not depend on templates which may not render anymore
+	exposeIngressURL := ingresses.GetExposeIngressURL(serviceToDelete)
+
+	if exposeIngressURL == constants.GLOBALLY {
+		configmaps.DeleteFromConfigMapGlobally(c.clientset, c.listers, serviceToDelete)
+	} else if exposeIngressURL == constants.LOCALLY {
+
+		configmaps.DeleteFromConfigMapLocally(c.clientset, c.listers, serviceToDelete)
+	}
+}
+
+// createIngressInfos renders the exposures of a service on all its tiers with the given configuration
+func (c *Controller) createIngressInfos(service *v1.Service, conf config.Configuration) ([]ingresses.IngressInfo, error) {
+	namespace, err := c.getNamespace(service.Namespace)
+	if err != nil {
+		logrus.Warnf("Can not fetch namespace: %v, its labels will not be available in templates: %v", service.Namespace, err)
+		namespace = nil
+	}
+
+	return ingresses.CreateIngressInfos(service, namespace, conf)
 }
 
 // handleErr checks if an error happened and makes sure we will retry later.
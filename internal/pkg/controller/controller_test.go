@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/dryrun"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/metrics"
+	"github.com/stakater/Xposer/internal/pkg/scope"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestServiceSelection(t *testing.T) {
+	const exposeAnnotation = "xposer.stakater.com/expose"
+
+	newService := func(labels map[string]string, annotations map[string]string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "team", Labels: labels, Annotations: annotations},
+			Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+		}
+	}
+	labeled := newService(map[string]string{constants.EXPOSE: "true"}, nil)
+	annotated := newService(nil, map[string]string{exposeAnnotation: "true"})
+	unexposed := newService(nil, nil)
+
+	tests := []struct {
+		name            string
+		old             *v1.Service
+		new             *v1.Service
+		namespaceLabels map[string]string
+		watched         string
+		generated       bool
+		handMade        bool
+		want            []string
+	}{
+		{
+			name: "service opting in with the annotation should be exposed",
+			old:  unexposed,
+			new:  annotated,
+			want: []string{"create app"},
+		},
+		{
+			name:      "service whose label is removed should not be exposed anymore",
+			old:       labeled,
+			new:       unexposed,
+			generated: true,
+			want:      []string{"delete app"},
+		},
+		{
+			name:            "service in an excluded namespace should not be exposed",
+			old:             unexposed,
+			new:             labeled,
+			namespaceLabels: map[string]string{"xposer": "off"},
+			want:            []string{},
+		},
+		{
+			name:    "service in a namespace which is not watched should not be exposed",
+			old:     unexposed,
+			new:     labeled,
+			watched: "other",
+			want:    []string{},
+		},
+		{
+			name:     "deleted service which was never exposed should keep the ingresses of others",
+			old:      unexposed,
+			handMade: true,
+			want:     []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.DefaultConfiguration()
+			conf.Domain = "example.com"
+			conf.ExposeAnnotation = exposeAnnotation
+			conf.ExcludeNamespaceSelector = "xposer=off"
+
+			objects := []runtime.Object{&v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "team", Labels: tt.namespaceLabels}}}
+			if tt.generated {
+				ingressInfo, err := ingresses.CreateIngressInfo(labeled, nil, conf)
+				if err != nil {
+					t.Fatalf("CreateIngressInfo() error = %v", err)
+				}
+				objects = append(objects, ingresses.CreateWithTLSFromIngressInfo(ingressInfo))
+			}
+			if tt.handMade {
+				objects = append(objects, &v1beta1.Ingress{ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "team"}})
+			}
+			clientset := fake.NewSimpleClientset(objects...)
+			watched, err := scope.Parse(tt.watched, "")
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			c := NewController(clientset, nil, conf, constants.KUBERNETES, watched, constants.RESYNC_PERIOD)
+
+			if tt.new != nil {
+				c.serviceUpdated(tt.old, tt.new)
+			} else {
+				c.serviceDeleted(tt.old)
+			}
+
+			got := []string{}
+			for _, action := range clientset.Actions() {
+				if action.GetResource().Resource != "ingresses" {
+					continue
+				}
+				switch action.GetVerb() {
+				case "create":
+					got = append(got, "create "+action.(k8stesting.CreateAction).GetObject().(meta_v1.Object).GetName())
+				case "delete":
+					got = append(got, "delete "+action.(k8stesting.DeleteAction).GetName())
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("actions = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("actions = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateConfig(t *testing.T) {
+	tests := []struct {
+		name           string
+		exposeSelector string
+		wantErr        bool
+	}{
+		{
+			name:           "unchanged selector should apply the configuration",
+			exposeSelector: "expose=true",
+		},
+		{
+			name:           "broadened selector should be rejected",
+			exposeSelector: "expose in (true,internal)",
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.DefaultConfiguration()
+			conf.Domain = "example.com"
+			c := NewController(fake.NewSimpleClientset(), nil, conf, constants.KUBERNETES, scope.Scope{}, constants.RESYNC_PERIOD)
+
+			reloaded := conf
+			reloaded.Domain = "reloaded.com"
+			reloaded.ExposeSelector = tt.exposeSelector
+			err := c.UpdateConfig(reloaded)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			wantDomain := reloaded.Domain
+			if tt.wantErr {
+				wantDomain = conf.Domain
+			}
+			if got := c.getConfig().Domain; got != wantDomain {
+				t.Errorf("UpdateConfig() domain = %v, want %v", got, wantDomain)
+			}
+		})
+	}
+}
+
+func TestResyncReportsDryRunChangeOnce(t *testing.T) {
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+	service := &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "app", Namespace: "team", Labels: map[string]string{constants.EXPOSE: "true"}},
+		Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+	}
+	clientset := fake.NewSimpleClientset(service)
+	c := NewController(clientset, nil, conf, constants.KUBERNETES, scope.Scope{}, constants.RESYNC_PERIOD)
+
+	// Like in client dry run, the created Ingress is reported but never stored, so every resync finds it missing
+	creates := 0
+	clientset.PrependReactor("create", "ingresses", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		creates++
+		ingress := action.(k8stesting.CreateAction).GetObject().(*v1beta1.Ingress)
+		c.RecordDryRunChange(dryrun.Change{Verb: "create", Resource: "ingresses", Namespace: ingress.Namespace, Name: ingress.Name})
+		return true, ingress, nil
+	})
+
+	before := dryRunChanges(t, "create", "ingresses")
+	c.serviceUpdated(service, service)
+	c.serviceUpdated(service, service)
+
+	if creates != 2 {
+		t.Fatalf("Expected both resyncs to create the Ingress, got %d creates", creates)
+	}
+	if got := dryRunChanges(t, "create", "ingresses") - before; got != 1 {
+		t.Errorf("Expected a single reported change, got %v", got)
+	}
+}
+
+func dryRunChanges(t *testing.T, verb string, resource string) float64 {
+	var metric dto.Metric
+	if err := metrics.DryRunChanges.WithLabelValues(verb, resource).Write(&metric); err != nil {
+		t.Fatalf("Can not read metric: %v", err)
+	}
+
+	return metric.GetCounter().GetValue()
+}
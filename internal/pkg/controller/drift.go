@@ -0,0 +1,263 @@
+package controller
+
+import (
+	"strings"
+	"time"
+
+	osV1 "github.com/openshift/api/route/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/metrics"
+	"github.com/stakater/Xposer/internal/pkg/ownership"
+	"github.com/stakater/Xposer/internal/pkg/routes"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Reasons of the events recorded on services when their generated object drifted
+const (
+	reasonDrifted  = "Drifted"
+	reasonReverted = "DriftReverted"
+)
+
+// newDriftInformer creates a shared informer on the Ingresses, or Routes on OpenShift, so that changes made by
+// others to generated objects are detected as soon as they happen. On Kubernetes it holds every Ingress, as it also
+// serves the lookups of Ingresses by service. On OpenShift it holds the generated Routes, or every Route with the
+// "all" host claim scope, so that hosts claimed by objects not generated by Xposer are known as well
+func (c *Controller) newDriftInformer() cache.SharedIndexInformer {
+	var informer cache.SharedIndexInformer
+
+	if c.clusterType == constants.OPENSHIFT {
+		if c.osClient == nil {
+			return nil
+		}
+
+		selector := ownership.Selector()
+		if c.config.HostClaimScope == config.HostClaimScopeAll {
+			selector = ""
+		}
+		listWatcher := &cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (k8sruntime.Object, error) {
+				options.LabelSelector = selector
+				return c.osClient.Routes(c.namespace).List(options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				options.LabelSelector = selector
+				return c.osClient.Routes(c.namespace).Watch(options)
+			},
+		}
+		informer = c.informerFactory.InformerFor(&osV1.Route{}, func(client kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+			return cache.NewSharedIndexInformer(listWatcher, &osV1.Route{}, resyncPeriod, cache.Indexers{})
+		})
+	} else {
+		informer = c.informerFactory.Extensions().V1beta1().Ingresses().Informer()
+		c.ingressLister = c.informerFactory.Extensions().V1beta1().Ingresses().Lister()
+	}
+
+	informer.AddIndexers(cache.Indexers{hostPathIndex: hostPathIndexFunc})
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			c.enqueueDrift(newObj)
+		},
+		DeleteFunc: c.enqueueDrift,
+	})
+
+	return informer
+}
+
+// enqueueDrift adds a 'drift' event for a generated object which was changed or deleted
+func (c *Controller) enqueueDrift(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if objectMeta, _ := driftObjectMeta(obj); objectMeta == nil || !ownership.IsManaged(*objectMeta) {
+		return
+	}
+
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err == nil {
+		c.queue.Add(Event{
+			key:       key,
+			eventType: "drift",
+			newObject: obj,
+		})
+	}
+}
+
+// driftDetected compares a generated object with what its service generates, and reverts or reports the difference
+// according to the drift policy
+func (c *Controller) driftDetected(obj interface{}) {
+	objectMeta, kind := driftObjectMeta(obj)
+	if objectMeta == nil {
+		return
+	}
+
+	// Objects in namespaces which are not watched may be managed by another instance of Xposer
+	if !c.inScope(objectMeta.Namespace) {
+		return
+	}
+
+	serviceNamespace, serviceName, ok := ownership.Owner(*objectMeta)
+	if !ok {
+		return
+	}
+
+	// Objects of deleted or not exposed services are expected to change, or to be deleted
+	serviceObj, exists, err := c.indexer.GetByKey(serviceNamespace + "/" + serviceName)
+	if err != nil {
+		return
+	}
+	if !exists {
+		c.removeOrphan(*objectMeta, kind)
+		return
+	}
+	service := serviceObj.(*v1.Service)
+	if !c.exposed(service) {
+		c.serviceDeleted(service)
+		return
+	}
+
+	if objectMeta.Annotations[constants.IGNORE_DRIFT_ANNOTATION] == "true" {
+		logrus.Debugf("%v: %v in namespace: %v opted out of drift detection", kind, objectMeta.Name, objectMeta.Namespace)
+		return
+	}
+
+	conf := c.getConfig()
+	if conf.DriftPolicy == config.DriftPolicyIgnore {
+		return
+	}
+
+	ingressInfos, err := c.createIngressInfos(service, conf)
+	if err != nil {
+		c.recordRenderError(service, err)
+		return
+	}
+
+	// The object may have been renamed by a template change, or belong to a tier the service left, the new one is
+	// checked on its own
+	var ingressInfo ingresses.IngressInfo
+	found := false
+	for _, info := range ingressInfos {
+		if info.IngressName == objectMeta.Name {
+			ingressInfo, found = info, true
+			break
+		}
+	}
+	if !found {
+		return
+	}
+
+	var drift []string
+	if c.clusterType == constants.OPENSHIFT {
+		drift = c.routeDrift(service, ingressInfo, conf.DriftPolicy)
+	} else {
+		drift = c.ingressDrift(service, ingressInfo, conf.DriftPolicy)
+	}
+	if len(drift) == 0 {
+		return
+	}
+
+	metrics.DriftDetected.WithLabelValues(kind, conf.DriftPolicy).Inc()
+	if conf.DriftPolicy == config.DriftPolicyRevert {
+		logrus.Infof("Reverted changes to %v of %v: %v in namespace: %v", strings.Join(drift, ", "), kind, objectMeta.Name, objectMeta.Namespace)
+		c.recorder.Eventf(service, v1.EventTypeNormal, reasonReverted, "Reverted changes to %v of %v %v", strings.Join(drift, ", "), kind, objectMeta.Name)
+	} else {
+		logrus.Warnf("%v: %v in namespace: %v drifted: %v", kind, objectMeta.Name, objectMeta.Namespace, strings.Join(drift, ", "))
+		c.recorder.Eventf(service, v1.EventTypeWarning, reasonDrifted, "%v %v drifted: %v", kind, objectMeta.Name, strings.Join(drift, ", "))
+	}
+}
+
+// ingressDrift returns the drifted fields of the generated Ingress, and reverts them if the policy says so
+func (c *Controller) ingressDrift(service *v1.Service, ingressInfo ingresses.IngressInfo, policy string) []string {
+	desired := ingresses.CreateWithTLSFromIngressInfo(ingressInfo)
+
+	obj, exists, err := c.driftIndexer.GetByKey(desired.Namespace + "/" + desired.Name)
+	if err != nil {
+		return nil
+	}
+	if !exists {
+		if policy == config.DriftPolicyRevert {
+			c.createIngress(service, desired)
+		}
+		return []string{"deleted"}
+	}
+
+	actual := obj.(*v1beta1.Ingress)
+	drift := ingresses.Diff(desired, actual)
+	if len(drift) > 0 && policy == config.DriftPolicyRevert {
+		err = c.applyIngress(desired)
+		if err != nil {
+			logrus.Errorf("Can not revert Ingress: %v, with error: %v", desired.Name, err)
+		}
+	}
+
+	return drift
+}
+
+// routeDrift returns the drifted fields of the generated Route, and reverts them if the policy says so
+func (c *Controller) routeDrift(service *v1.Service, ingressInfo ingresses.IngressInfo, policy string) []string {
+	desired := routes.Create(ingressInfo.IngressName, ingressInfo.Namespace, ingressInfo.ForwardAnnotationsMap,
+		ingressInfo.IngressHost, ingressInfo.IngressPath, ingressInfo.ServiceName, ingressInfo.ServicePort)
+
+	obj, exists, err := c.driftIndexer.GetByKey(desired.Namespace + "/" + desired.Name)
+	if err != nil {
+		return nil
+	}
+	if !exists {
+		if policy == config.DriftPolicyRevert {
+			c.createRoute(service, desired)
+		}
+		return []string{"deleted"}
+	}
+
+	actual := obj.(*osV1.Route)
+	drift := routes.Diff(desired, actual)
+	if len(drift) > 0 && policy == config.DriftPolicyRevert {
+		err = c.applyRoute(desired)
+		if err != nil {
+			logrus.Errorf("Can not revert Route: %v, with error: %v", desired.Name, err)
+		}
+	}
+
+	return drift
+}
+
+// removeOrphan deletes a generated object whose service is not watched anymore, as it was deleted or its label was
+// removed while the watch missed it, e.g. while Xposer was not running. The services must be listed first, and an
+// object which was deleted itself is not in the drift informer anymore
+func (c *Controller) removeOrphan(objectMeta meta_v1.ObjectMeta, kind string) {
+	if !c.servicesSynced() {
+		return
+	}
+	if _, exists, err := c.driftIndexer.GetByKey(objectMeta.Namespace + "/" + objectMeta.Name); err != nil || !exists {
+		return
+	}
+
+	err := c.deleteGenerated(objectMeta)
+	if err != nil && !errors.IsNotFound(err) {
+		logrus.Errorf("Can not delete %v: %v of service: %v, which is not exposed anymore, with error: %v", kind,
+			objectMeta.Name, objectMeta.Annotations[constants.OWNER_ANNOTATION], err)
+		return
+	}
+	logrus.Infof("Deleted %v: %v in namespace: %v, its service: %v is not exposed anymore", kind, objectMeta.Name,
+		objectMeta.Namespace, objectMeta.Annotations[constants.OWNER_ANNOTATION])
+}
+
+func driftObjectMeta(obj interface{}) (*meta_v1.ObjectMeta, string) {
+	switch typed := obj.(type) {
+	case *v1beta1.Ingress:
+		return &typed.ObjectMeta, "Ingress"
+	case *osV1.Route:
+		return &typed.ObjectMeta, "Route"
+	}
+
+	return nil, ""
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stakater/Xposer/internal/pkg/apply"
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/scope"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDriftDetected(t *testing.T) {
+	service := &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "app",
+			Namespace: "team",
+			Labels:    map[string]string{constants.EXPOSE: "true"},
+		},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+	}
+
+	tests := []struct {
+		name       string
+		policy     string
+		changeHost bool
+		deleted    bool
+		optOut     bool
+		unexposed  bool
+		wantAction string
+	}{
+		{
+			name:       "changed ingress should be reverted",
+			policy:     config.DriftPolicyRevert,
+			changeHost: true,
+			wantAction: "patch",
+		},
+		{
+			name:       "deleted ingress should be recreated",
+			policy:     config.DriftPolicyRevert,
+			deleted:    true,
+			wantAction: "create",
+		},
+		{
+			name:       "changed ingress should only be reported",
+			policy:     config.DriftPolicyReport,
+			changeHost: true,
+		},
+		{
+			name:       "ingress which opted out should not be reverted",
+			policy:     config.DriftPolicyRevert,
+			changeHost: true,
+			optOut:     true,
+		},
+		{
+			name:   "unchanged ingress should not be updated",
+			policy: config.DriftPolicyRevert,
+		},
+		{
+			name:       "ingress of a service which is not exposed anymore should be deleted",
+			policy:     config.DriftPolicyIgnore,
+			unexposed:  true,
+			wantAction: "delete",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.DefaultConfiguration()
+			conf.Domain = "example.com"
+			conf.DriftPolicy = tt.policy
+
+			ingressInfo, err := ingresses.CreateIngressInfo(service, nil, conf)
+			if err != nil {
+				t.Fatalf("CreateIngressInfo() error = %v", err)
+			}
+			ingress := ingresses.CreateWithTLSFromIngressInfo(ingressInfo)
+			if err := apply.RecordLastApplied(ingress, &ingress.ObjectMeta); err != nil {
+				t.Fatalf("RecordLastApplied() error = %v", err)
+			}
+			if tt.changeHost {
+				ingress.Spec.Rules[0].Host = "changed.example.com"
+			}
+			if tt.optOut {
+				ingress.Annotations[constants.IGNORE_DRIFT_ANNOTATION] = "true"
+			}
+
+			objects := []runtime.Object{}
+			if !tt.deleted {
+				objects = append(objects, ingress)
+			}
+			clientset := newPatchingClientset(objects...)
+			c := NewController(clientset, nil, conf, constants.KUBERNETES, scope.Scope{}, constants.RESYNC_PERIOD)
+			c.servicesSynced = func() bool { return true }
+			if !tt.unexposed {
+				c.indexer.Add(service)
+			}
+			if !tt.deleted {
+				c.driftIndexer.Add(ingress)
+			}
+
+			c.driftDetected(ingress)
+
+			action := ""
+			for _, a := range clientset.Actions() {
+				if a.GetResource().Resource == "ingresses" && (a.GetVerb() == "create" || a.GetVerb() == "patch" || a.GetVerb() == "delete") {
+					action = a.GetVerb()
+				}
+			}
+			if action != tt.wantAction {
+				t.Errorf("driftDetected() action = %q, want %q", action, tt.wantAction)
+			}
+			if action == "patch" {
+				reverted, err := clientset.ExtensionsV1beta1().Ingresses(service.Namespace).Get(ingress.Name, meta_v1.GetOptions{})
+				if err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+				if diff := ingresses.Diff(ingresses.CreateWithTLSFromIngressInfo(ingressInfo), reverted); len(diff) > 0 {
+					t.Errorf("driftDetected() reverted to %v", diff)
+				}
+			}
+		})
+	}
+}
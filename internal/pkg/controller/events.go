@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stakater/Xposer/internal/pkg/dryrun"
+	"github.com/stakater/Xposer/internal/pkg/metrics"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Reasons of the events recorded on services
+const (
+	reasonInvalidTemplate = "InvalidTemplate"
+	reasonDryRun          = "DryRun"
+)
+
+// kinds of the resources Xposer writes, to record dry run events on them
+var kinds = map[string]string{
+	"ingresses":  "Ingress",
+	"routes":     "Route",
+	"configmaps": "ConfigMap",
+	"services":   "Service",
+}
+
+// recordRenderError reports a service which is not exposed because one of its templates can not be rendered
+func (c *Controller) recordRenderError(service *v1.Service, err error) {
+	logrus.Errorf("Service: %v in namespace: %v is not exposed: %v", service.Name, service.Namespace, err)
+	c.recorder.Eventf(service, v1.EventTypeWarning, reasonInvalidTemplate, "Service is not exposed: %v", err)
+}
+
+// RecordDryRunChange reports a change which was not applied because of dry run. The event is recorded on the service
+// the object was generated for if it is known, or on the object itself. A change is reported once per verb and
+// object, as the resyncs of services which look unexposed try it again
+func (c *Controller) RecordDryRunChange(change dryrun.Change) {
+	key := change
+	key.Owner = ""
+	c.reportedLock.Lock()
+	reported := c.reportedChanges[key]
+	c.reportedChanges[key] = true
+	c.reportedLock.Unlock()
+	if reported {
+		return
+	}
+
+	logrus.Infof("Dry run: would %v %v: %v in namespace: %v", change.Verb, change.Resource, change.Name, change.Namespace)
+	metrics.DryRunChanges.WithLabelValues(change.Verb, change.Resource).Inc()
+
+	reference := &v1.ObjectReference{
+		Kind:      kinds[change.Resource],
+		Namespace: change.Namespace,
+		Name:      change.Name,
+	}
+	if owner := strings.SplitN(change.Owner, "/", 2); len(owner) == 2 {
+		reference = &v1.ObjectReference{
+			Kind:       "Service",
+			APIVersion: "v1",
+			Namespace:  owner[0],
+			Name:       owner[1],
+		}
+	}
+	if reference.Kind == "" || reference.Name == "" {
+		return
+	}
+
+	c.recorder.Eventf(reference, v1.EventTypeNormal, reasonDryRun, "Dry run: would %v %v %v/%v",
+		change.Verb, change.Resource, change.Namespace, change.Name)
+}
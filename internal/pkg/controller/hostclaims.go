@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	osV1 "github.com/openshift/api/route/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/metrics"
+	"github.com/stakater/Xposer/internal/pkg/ownership"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// hostPathIndex indexes the Ingresses or Routes of the drift informer by the hosts and paths they claim
+	hostPathIndex = "hostPath"
+
+	reasonHostConflict = "HostConflict"
+)
+
+// hostPathIndexFunc returns a key for every host and path claimed by an Ingress or Route
+func hostPathIndexFunc(obj interface{}) ([]string, error) {
+	keys := []string{}
+
+	switch typed := obj.(type) {
+	case *v1beta1.Ingress:
+		for _, rule := range typed.Spec.Rules {
+			if rule.Host == "" || rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				keys = append(keys, hostPathKey(rule.Host, path.Path))
+			}
+		}
+	case *osV1.Route:
+		if typed.Spec.Host != "" {
+			keys = append(keys, hostPathKey(typed.Spec.Host, typed.Spec.Path))
+		}
+	}
+
+	return keys, nil
+}
+
+// hostPathKey returns the key of a host and path, hosts are case insensitive and an empty path is the root path
+func hostPathKey(host string, path string) string {
+	if path == "" {
+		path = "/"
+	}
+
+	return strings.ToLower(host) + path
+}
+
+// hostClaimedBy describes the object which claimed the host and path of a service first, and is empty if the host
+// and path are free or the service claimed them first
+func (c *Controller) hostClaimedBy(service *v1.Service, ingressInfo ingresses.IngressInfo) string {
+	if c.driftIndexer == nil || ingressInfo.IngressHost == "" {
+		return ""
+	}
+
+	claims, err := c.driftIndexer.ByIndex(hostPathIndex, hostPathKey(ingressInfo.IngressHost, ingressInfo.IngressPath))
+	if err != nil {
+		logrus.Warnf("Can not look up claims of host: %v, with error: %v", ingressInfo.IngressHost, err)
+		return ""
+	}
+
+	var own *meta_v1.ObjectMeta
+	if obj, exists, err := c.driftIndexer.GetByKey(service.Namespace + "/" + ingressInfo.IngressName); err == nil && exists {
+		own, _ = driftObjectMeta(obj)
+	}
+
+	for _, claim := range claims {
+		objectMeta, kind := driftObjectMeta(claim)
+		if objectMeta == nil {
+			continue
+		}
+
+		// Only generated objects hold claims, unless the host claim scope is "all"
+		if c.getConfig().HostClaimScope != config.HostClaimScopeAll && !ownership.IsManaged(*objectMeta) {
+			continue
+		}
+		// The object with the generated name is handled by the adoption policy, when it is created or updated
+		if objectMeta.Namespace == service.Namespace && objectMeta.Name == ingressInfo.IngressName {
+			continue
+		}
+		if ownerNamespace, ownerName, ok := ownership.Owner(*objectMeta); ok && ownerNamespace == service.Namespace && ownerName == service.Name {
+			continue
+		}
+		if own != nil && claimedBefore(*own, *objectMeta) {
+			continue
+		}
+
+		return fmt.Sprintf("%v %v/%v", kind, objectMeta.Namespace, objectMeta.Name)
+	}
+
+	return ""
+}
+
+// claimedBefore returns true if the first object was created before the second one. Objects created in the same
+// second are ordered by namespace and name, so that exactly one of them keeps the claim
+func claimedBefore(first meta_v1.ObjectMeta, second meta_v1.ObjectMeta) bool {
+	if !first.CreationTimestamp.Equal(&second.CreationTimestamp) {
+		return first.CreationTimestamp.Before(&second.CreationTimestamp)
+	}
+
+	return first.Namespace+"/"+first.Name < second.Namespace+"/"+second.Name
+}
+
+// checkHostClaim returns false if the host and path of a service are already claimed, in which case the service is
+// not exposed. The conflict is recorded as an event, in the status annotation of the service and as a metric
+func (c *Controller) checkHostClaim(service *v1.Service, ingressInfo ingresses.IngressInfo) bool {
+	return len(c.checkHostClaims(service, []ingresses.IngressInfo{ingressInfo})) == 1
+}
+
+// checkHostClaims returns the exposures of a service, on its tiers, whose hosts and paths are not already claimed.
+// The conflicts are recorded like by checkHostClaim, the status of the service lists all of them
+func (c *Controller) checkHostClaims(service *v1.Service, ingressInfos []ingresses.IngressInfo) []ingresses.IngressInfo {
+	free := []ingresses.IngressInfo{}
+	conflicts := []string{}
+
+	for _, ingressInfo := range ingressInfos {
+		claimant := c.hostClaimedBy(service, ingressInfo)
+		if claimant == "" {
+			free = append(free, ingressInfo)
+			continue
+		}
+
+		logrus.Warnf("Service: %v in namespace: %v is not exposed, host: %v and path: %v are already claimed by %v",
+			service.Name, service.Namespace, ingressInfo.IngressHost, ingressInfo.IngressPath, claimant)
+		c.recorder.Eventf(service, v1.EventTypeWarning, reasonHostConflict, "Service is not exposed, host %v and path %v are already claimed by %v",
+			ingressInfo.IngressHost, ingressInfo.IngressPath, claimant)
+		conflicts = append(conflicts, fmt.Sprintf("host %v and path %v are already claimed by %v",
+			ingressInfo.IngressHost, ingressInfo.IngressPath, claimant))
+	}
+
+	if len(conflicts) == 0 {
+		metrics.HostConflicts.DeleteLabelValues(service.Namespace, service.Name)
+		c.setStatus(service, "")
+		return free
+	}
+
+	metrics.HostConflicts.WithLabelValues(service.Namespace, service.Name).Set(1)
+	c.setStatus(service, fmt.Sprintf("%v: %v", reasonHostConflict, strings.Join(conflicts, "; ")))
+
+	return free
+}
+
+// setStatus sets the status annotation of a service, or removes it if the status is empty. The service is only
+// patched if its status changes
+func (c *Controller) setStatus(service *v1.Service, status string) {
+	if service.Annotations[constants.STATUS_ANNOTATION] == status {
+		return
+	}
+
+	// A null value removes the annotation in a merge patch
+	var value interface{}
+	if status != "" {
+		value = status
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{constants.STATUS_ANNOTATION: value},
+		},
+	})
+	if err != nil {
+		logrus.Errorf("Can not create status patch of service: %v, with error: %v", service.Name, err)
+		return
+	}
+
+	_, err = c.clientset.CoreV1().Services(service.Namespace).Patch(service.Name, types.MergePatchType, patch)
+	if err != nil {
+		logrus.Warnf("Can not set status of service: %v in namespace: %v, with error: %v", service.Name, service.Namespace, err)
+	}
+}
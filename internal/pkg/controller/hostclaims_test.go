@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/ownership"
+	"github.com/stakater/Xposer/internal/pkg/scope"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCheckHostClaim(t *testing.T) {
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+	conf.IngressURLTemplate = "{{.Service}}.{{.Domain}}"
+
+	newService := func(namespace string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "app",
+				Namespace: namespace,
+				Labels:    map[string]string{constants.EXPOSE: "true"},
+			},
+			Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+		}
+	}
+	earlier := meta_v1.NewTime(time.Now().Add(-time.Hour))
+	later := meta_v1.NewTime(time.Now())
+
+	tests := []struct {
+		name       string
+		otherPath  string
+		ownCreated *meta_v1.Time
+		unmanaged  bool
+		scope      string
+		want       bool
+	}{
+		{
+			name:      "host claimed by another service should be refused",
+			otherPath: "/",
+			want:      false,
+		},
+		{
+			name:      "host claimed by an object not generated by Xposer should be refused",
+			otherPath: "/",
+			unmanaged: true,
+			scope:     config.HostClaimScopeAll,
+			want:      false,
+		},
+		{
+			name:      "object not generated by Xposer should not claim hosts in managed scope",
+			otherPath: "/",
+			unmanaged: true,
+			want:      true,
+		},
+		{
+			name:      "same host with another path should be allowed",
+			otherPath: "/other",
+			want:      true,
+		},
+		{
+			name:       "service which claimed the host first should keep it",
+			otherPath:  "/",
+			ownCreated: &earlier,
+			want:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newService("a")
+			clientset := fake.NewSimpleClientset(service)
+			conf := conf
+			if tt.scope != "" {
+				conf.HostClaimScope = tt.scope
+			}
+			c := NewController(clientset, nil, conf, constants.KUBERNETES, scope.Scope{}, constants.RESYNC_PERIOD)
+
+			ingressInfo, err := ingresses.CreateIngressInfo(service, nil, conf)
+			if err != nil {
+				t.Fatalf("CreateIngressInfo() error = %v", err)
+			}
+			if tt.ownCreated != nil {
+				own := ingresses.CreateWithTLSFromIngressInfo(ingressInfo)
+				own.CreationTimestamp = *tt.ownCreated
+				c.driftIndexer.Add(own)
+			}
+
+			otherInfo, err := ingresses.CreateIngressInfo(newService("b"), nil, conf)
+			if err != nil {
+				t.Fatalf("CreateIngressInfo() error = %v", err)
+			}
+			otherInfo.IngressPath = tt.otherPath
+			other := ingresses.CreateWithTLSFromIngressInfo(otherInfo)
+			other.CreationTimestamp = later
+			if tt.unmanaged {
+				other.Labels = nil
+				other.Annotations = nil
+			} else if _, _, ok := ownership.Owner(other.ObjectMeta); !ok {
+				t.Fatalf("generated Ingress should have an owner")
+			}
+			c.driftIndexer.Add(other)
+
+			if got := c.checkHostClaim(service, ingressInfo); got != tt.want {
+				t.Errorf("checkHostClaim() = %v, want %v", got, tt.want)
+			}
+
+			// The fake clientset does not apply patches, the patch itself is checked
+			status := ""
+			for _, action := range clientset.Actions() {
+				if patch, ok := action.(k8stesting.PatchAction); ok && action.GetResource().Resource == "services" {
+					status = string(patch.GetPatch())
+				}
+			}
+			if hasStatus := strings.Contains(status, reasonHostConflict); hasStatus == tt.want {
+				t.Errorf("checkHostClaim() status patch = %q", status)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/permissions"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// startInformers starts the shared informers and returns the functions telling whether their caches are synced. The
+// namespace and xposer configmap informers are optional, they are only started with the permissions to list and
+// watch namespaces and configmaps, and the API server is asked instead otherwise
+func (c *Controller) startInformers(stopCh <-chan struct{}) []cache.InformerSynced {
+	cacheSyncs := []cache.InformerSynced{}
+	if c.driftInformer != nil {
+		cacheSyncs = append(cacheSyncs, c.driftInformer.HasSynced)
+	}
+
+	if c.mayListAndWatch("namespaces", "") {
+		informer := c.informerFactory.Core().V1().Namespaces()
+		c.listers.Namespaces = informer.Lister()
+		informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{UpdateFunc: c.namespaceUpdated})
+		cacheSyncs = append(cacheSyncs, informer.Informer().HasSynced)
+	} else {
+		logrus.Infof("Namespaces are not cached, the permissions to list and watch them are missing")
+	}
+
+	// Configmaps are cached in all namespaces if possible, as URLs may be published globally, in the watched one otherwise
+	configMapNamespace, cached := "", c.mayListAndWatch("configmaps", "")
+	if !cached && c.namespace != "" {
+		configMapNamespace, cached = c.namespace, c.mayListAndWatch("configmaps", c.namespace)
+	}
+	if cached {
+		informer := c.informerFactory.InformerFor(&v1.ConfigMap{}, func(client kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+			return coreinformers.NewFilteredConfigMapInformer(client, configMapNamespace, resyncPeriod,
+				cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, func(options *meta_v1.ListOptions) {
+					options.FieldSelector = fields.OneTermEqualSelector("metadata.name", constants.XPOSER_CONFIGMAP).String()
+				})
+		})
+		c.listers.ConfigMaps = corelisters.NewConfigMapLister(informer.GetIndexer())
+		c.listers.ConfigMapsNamespace = configMapNamespace
+		cacheSyncs = append(cacheSyncs, informer.HasSynced)
+	} else {
+		logrus.Infof("Configmaps are not cached, the permissions to list and watch them are missing")
+	}
+
+	c.informerFactory.Start(stopCh)
+	return cacheSyncs
+}
+
+// namespaceUpdated re-enqueues the services of a namespace whose labels changed, as it may have entered or left the
+// watched namespaces or those selected by the configuration. The services of a namespace which left are not exposed
+// anymore. Without the namespace informer, this is only noticed on resync
+func (c *Controller) namespaceUpdated(oldObj interface{}, newObj interface{}) {
+	oldNamespace, newNamespace := oldObj.(*v1.Namespace), newObj.(*v1.Namespace)
+	if labels.Equals(labels.Set(oldNamespace.Labels), labels.Set(newNamespace.Labels)) {
+		return
+	}
+
+	for _, obj := range c.indexer.List() {
+		service := obj.(*v1.Service)
+		if service.Namespace != newNamespace.Name {
+			continue
+		}
+
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err == nil {
+			c.queue.Add(Event{
+				key:       key,
+				eventType: "reload",
+				newObject: obj,
+			})
+		}
+	}
+}
+
+// mayListAndWatch asks the API server whether the controller may list and watch a resource in a namespace, all
+// namespaces if empty
+func (c *Controller) mayListAndWatch(resource string, namespace string) bool {
+	results, err := permissions.Check(c.clientset, []permissions.Permission{
+		{Resource: resource, Verb: "list", Namespace: namespace},
+		{Resource: resource, Verb: "watch", Namespace: namespace},
+	})
+	if err != nil {
+		logrus.Warnf("Can not check permissions to cache %v: %v", resource, err)
+		return false
+	}
+
+	return len(permissions.Missing(results)) == 0
+}
+
+// listIngresses returns the Ingresses of a namespace, read from the cache of the shared informer once it is synced
+func (c *Controller) listIngresses(namespace string) (*v1beta1.IngressList, error) {
+	if c.ingressLister == nil || !c.driftInformer.HasSynced() {
+		return c.clientset.ExtensionsV1beta1().Ingresses(namespace).List(meta_v1.ListOptions{})
+	}
+
+	cached, err := c.ingressLister.Ingresses(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	ingressList := &v1beta1.IngressList{}
+	for _, ingress := range cached {
+		ingressList.Items = append(ingressList.Items, *ingress)
+	}
+
+	return ingressList, nil
+}
+
+// getNamespace returns a namespace, read from the cache of the shared informer if it is available
+func (c *Controller) getNamespace(name string) (*v1.Namespace, error) {
+	if c.listers.Namespaces != nil {
+		return c.listers.Namespaces.Get(name)
+	}
+
+	return c.clientset.CoreV1().Namespaces().Get(name, meta_v1.GetOptions{})
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stakater/Xposer/internal/pkg/apply"
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/configmaps"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/scope"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newExposureFixture returns a controller for a service exposed in one of the given number of namespaces, which
+// publishes its URL globally. With cached, the shared informers are started and synced
+func newExposureFixture(tb testing.TB, namespaces int, cached bool, stopCh chan struct{}) (*Controller, *fake.Clientset, *v1.Service) {
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+
+	service := &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "app",
+			Namespace:   "ns-0",
+			Labels:      map[string]string{constants.EXPOSE: "true"},
+			Annotations: map[string]string{constants.FORWARD_ANNOTATION: constants.EXPOSE_INGRESS_URL + ": " + constants.GLOBALLY},
+		},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+	}
+	ingressInfo, err := ingresses.CreateIngressInfo(service, nil, conf)
+	if err != nil {
+		tb.Fatalf("CreateIngressInfo() error = %v", err)
+	}
+	ingress := ingresses.CreateWithTLSFromIngressInfo(ingressInfo)
+	if err := apply.RecordLastApplied(ingress, &ingress.ObjectMeta); err != nil {
+		tb.Fatalf("RecordLastApplied() error = %v", err)
+	}
+
+	objects := []runtime.Object{service, ingress}
+	for i := 0; i < namespaces; i++ {
+		name := fmt.Sprintf("ns-%d", i)
+		objects = append(objects, &v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: name}},
+			configmaps.CreateConfigMapObject(name, map[string]string{configmaps.ConfigMapKey(service): ingressInfo.IngressHost}))
+	}
+	clientset := fake.NewSimpleClientset(objects...)
+	clientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = true
+		return true, review, nil
+	})
+
+	c := NewController(clientset, nil, conf, constants.KUBERNETES, scope.Scope{}, constants.RESYNC_PERIOD)
+	if cached && !cache.WaitForCacheSync(stopCh, c.startInformers(stopCh)...) {
+		tb.Fatalf("caches did not sync")
+	}
+	clientset.ClearActions()
+
+	return c, clientset, service
+}
+
+// reads returns the get and list calls the controller made to the API server
+func reads(clientset *fake.Clientset) []string {
+	calls := []string{}
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "get" || action.GetVerb() == "list" {
+			calls = append(calls, action.GetVerb()+" "+action.GetResource().Resource)
+		}
+	}
+
+	return calls
+}
+
+// writes returns the create, update, patch and delete calls the controller made to the API server
+func writes(clientset *fake.Clientset) []string {
+	calls := []string{}
+	for _, action := range clientset.Actions() {
+		switch action.GetVerb() {
+		case "create", "update", "patch", "delete":
+			calls = append(calls, action.GetVerb()+" "+action.GetResource().Resource)
+		}
+	}
+
+	return calls
+}
+
+func TestUpdateExposureSkipsUnchangedConfigMaps(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	c, clientset, service := newExposureFixture(t, 3, true, stopCh)
+
+	c.updateExposure(service, service)
+
+	for _, call := range writes(clientset) {
+		if call == "update configmaps" {
+			t.Errorf("updateExposure() writes = %v, want no update of configmaps publishing the same URL", writes(clientset))
+			break
+		}
+	}
+}
+
+func TestUpdateExposureReadsFromCache(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	c, clientset, service := newExposureFixture(t, 3, true, stopCh)
+
+	c.updateExposure(service, service)
+	c.serviceUpdated(service, service)
+
+	// Only the Ingress is read before patching it, so that the patch is computed against its latest version
+	want := []string{"get ingresses"}
+	if got := reads(clientset); len(got) != len(want) || got[0] != want[0] {
+		t.Errorf("updateExposure() reads = %v, want %v", got, want)
+	}
+}
+
+func TestNamespaceUpdated(t *testing.T) {
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+	c := NewController(fake.NewSimpleClientset(), nil, conf, constants.KUBERNETES, scope.Scope{}, constants.RESYNC_PERIOD)
+	for _, namespace := range []string{"team", "team", "other"} {
+		service := &v1.Service{ObjectMeta: meta_v1.ObjectMeta{Name: fmt.Sprintf("app-%d", len(c.indexer.ListKeys())), Namespace: namespace}}
+		if err := c.indexer.Add(service); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	oldNamespace := &v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "team", Labels: map[string]string{"xposer": "enabled"}}}
+	c.namespaceUpdated(oldNamespace, oldNamespace.DeepCopy())
+	if got := c.queue.Len(); got != 0 {
+		t.Errorf("namespaceUpdated() with unchanged labels enqueued %v services, want 0", got)
+	}
+
+	newNamespace := oldNamespace.DeepCopy()
+	newNamespace.Labels["xposer"] = "disabled"
+	c.namespaceUpdated(oldNamespace, newNamespace)
+	if got := c.queue.Len(); got != 2 {
+		t.Errorf("namespaceUpdated() enqueued %v services, want 2", got)
+	}
+}
+
+func BenchmarkUpdateExposure(b *testing.B) {
+	for _, cached := range []bool{false, true} {
+		name := "api"
+		if cached {
+			name = "cache"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			c, clientset, service := newExposureFixture(b, 50, cached, stopCh)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.updateExposure(service, service)
+			}
+			b.StopTimer()
+
+			b.Logf("%d API calls, %d of them reads and %d writes, per update of a service published in 50 namespaces",
+				len(clientset.Actions())/b.N, len(reads(clientset))/b.N, len(writes(clientset))/b.N)
+		})
+	}
+}
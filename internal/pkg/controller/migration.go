@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ownership"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+const reasonMigrated = "Migrated"
+
+// migrate deletes the objects generated for the service before, whose identity differs from the identities of the
+// objects it generates now, e.g. after its name changed or it left a tier. It must only be called once the new objects
+// exist, so that the service stays exposed during the migration
+func (c *Controller) migrate(service *v1.Service, desired []meta_v1.ObjectMeta) {
+	kind := c.generatedKind()
+	desiredIdentities := make(map[string]bool)
+	desiredNames := []string{}
+	for _, objectMeta := range desired {
+		desiredIdentities[ownership.Identity(kind, objectMeta)] = true
+		desiredNames = append(desiredNames, objectMeta.Name)
+	}
+	replacement := strings.Join(desiredNames, ", ")
+
+	generated, err := c.ownedGenerated(service)
+	if err != nil {
+		logrus.Errorf("Can not list the %vs generated in namespace: %v, with error: %v", kind, service.Namespace, err)
+		return
+	}
+
+	for _, objectMeta := range generated {
+		if desiredIdentities[ownership.AppliedIdentity(kind, objectMeta)] {
+			continue
+		}
+
+		err = c.deleteGenerated(objectMeta)
+		if err != nil {
+			logrus.Errorf("Can not delete %v: %v replaced by: %v, with error: %v", kind, objectMeta.Name, replacement, err)
+			continue
+		}
+		logrus.Infof("Replaced %v: %v by: %v in namespace: %v", kind, objectMeta.Name, replacement, service.Namespace)
+		c.recorder.Eventf(service, v1.EventTypeNormal, reasonMigrated, "Replaced %v %v by %v", kind, objectMeta.Name, replacement)
+	}
+}
+
+// ownedGenerated returns the metadata of the objects generated for the service
+func (c *Controller) ownedGenerated(service *v1.Service) ([]meta_v1.ObjectMeta, error) {
+	generated, err := c.listGenerated(service.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := []meta_v1.ObjectMeta{}
+	for _, objectMeta := range generated {
+		ownerNamespace, ownerName, ok := ownership.Owner(objectMeta)
+		if ok && ownerNamespace == service.Namespace && ownerName == service.Name {
+			owned = append(owned, objectMeta)
+		}
+	}
+
+	return owned, nil
+}
+
+// listGenerated returns the metadata of the Ingresses, or Routes on OpenShift, generated in the given namespace. They
+// are read from the cache of the drift informer once it is synced
+func (c *Controller) listGenerated(namespace string) ([]meta_v1.ObjectMeta, error) {
+	options := meta_v1.ListOptions{LabelSelector: ownership.Selector()}
+	generated := []meta_v1.ObjectMeta{}
+
+	if c.driftInformer != nil && c.driftInformer.HasSynced() {
+		selector, err := labels.Parse(options.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
+		err = cache.ListAllByNamespace(c.driftIndexer, namespace, selector, func(obj interface{}) {
+			if objectMeta, _ := driftObjectMeta(obj); objectMeta != nil {
+				generated = append(generated, *objectMeta)
+			}
+		})
+		return generated, err
+	}
+
+	if c.clusterType == constants.OPENSHIFT {
+		routeList, err := c.osClient.Routes(namespace).List(options)
+		if err != nil {
+			return nil, err
+		}
+		for _, route := range routeList.Items {
+			generated = append(generated, route.ObjectMeta)
+		}
+	} else {
+		ingressList, err := c.clientset.ExtensionsV1beta1().Ingresses(namespace).List(options)
+		if err != nil {
+			return nil, err
+		}
+		for _, ingress := range ingressList.Items {
+			generated = append(generated, ingress.ObjectMeta)
+		}
+	}
+
+	return generated, nil
+}
+
+// generatedKind returns the kind of the objects generated for services, Route on OpenShift and Ingress otherwise
+func (c *Controller) generatedKind() string {
+	if c.clusterType == constants.OPENSHIFT {
+		return "Route"
+	}
+
+	return "Ingress"
+}
+
+func (c *Controller) deleteGenerated(objectMeta meta_v1.ObjectMeta) error {
+	if c.clusterType == constants.OPENSHIFT {
+		return c.osClient.Routes(objectMeta.Namespace).Delete(objectMeta.Name, &meta_v1.DeleteOptions{})
+	}
+
+	return c.clientset.ExtensionsV1beta1().Ingresses(objectMeta.Namespace).Delete(objectMeta.Name, &meta_v1.DeleteOptions{})
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/scope"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestUpdateExposureMigratesRenamedIngress(t *testing.T) {
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+
+	newService := func(name string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      name,
+				Namespace: "team",
+				Labels:    map[string]string{constants.EXPOSE: "true"},
+			},
+			Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+		}
+	}
+	service := newService("app")
+
+	// Ingresses generated with a previous name template, for the service and for another one
+	oldConf := conf
+	oldConf.IngressNameTemplate = "{{.Service}}-old"
+	oldInfo, err := ingresses.CreateIngressInfo(service, nil, oldConf)
+	if err != nil {
+		t.Fatalf("CreateIngressInfo() error = %v", err)
+	}
+	otherInfo, err := ingresses.CreateIngressInfo(newService("other"), nil, oldConf)
+	if err != nil {
+		t.Fatalf("CreateIngressInfo() error = %v", err)
+	}
+
+	clientset := fake.NewSimpleClientset(service, ingresses.CreateWithTLSFromIngressInfo(oldInfo),
+		ingresses.CreateWithTLSFromIngressInfo(otherInfo))
+	c := NewController(clientset, nil, conf, constants.KUBERNETES, scope.Scope{}, constants.RESYNC_PERIOD)
+
+	c.updateExposure(service, service)
+
+	verbs := []string{}
+	for _, action := range clientset.Actions() {
+		if action.GetResource().Resource != "ingresses" {
+			continue
+		}
+		switch action.GetVerb() {
+		case "create":
+			verbs = append(verbs, "create "+action.(k8stesting.CreateAction).GetObject().(meta_v1.Object).GetName())
+		case "delete":
+			verbs = append(verbs, "delete "+action.(k8stesting.DeleteAction).GetName())
+		}
+	}
+
+	want := []string{"create app", "delete app-old"}
+	if len(verbs) != len(want) || verbs[0] != want[0] || verbs[1] != want[1] {
+		t.Errorf("updateExposure() = %v, want %v", verbs, want)
+	}
+	if _, err := clientset.ExtensionsV1beta1().Ingresses("team").Get("other-old", meta_v1.GetOptions{}); err != nil {
+		t.Errorf("updateExposure() should keep the Ingress of another service: %v", err)
+	}
+}
+
+func TestUpdateExposureWithTiers(t *testing.T) {
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+	conf.Tiers = map[string]config.Tier{
+		"internal": {Domain: "internal.example.com"},
+		"public":   {Domain: "example.org"},
+	}
+
+	service := &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "app",
+			Namespace:   "team",
+			Labels:      map[string]string{constants.EXPOSE: "true"},
+			Annotations: map[string]string{constants.TIERS_ANNOTATION: "internal,public"},
+		},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+	}
+	clientset := fake.NewSimpleClientset(service)
+	c := NewController(clientset, nil, conf, constants.KUBERNETES, scope.Scope{}, constants.RESYNC_PERIOD)
+
+	hosts := func() map[string]string {
+		ingressList, err := clientset.ExtensionsV1beta1().Ingresses("team").List(meta_v1.ListOptions{})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		got := map[string]string{}
+		for _, ingress := range ingressList.Items {
+			got[ingress.Name] = ingress.Spec.Rules[0].Host
+		}
+		return got
+	}
+
+	c.serviceCreated(service)
+	got := hosts()
+	if len(got) != 2 || got["app-internal"] != "app.team.internal.example.com" || got["app-public"] != "app.team.example.org" {
+		t.Errorf("serviceCreated() ingresses = %v, want one per tier", got)
+	}
+
+	// The service leaves the internal tier, its Ingress is deleted
+	updated := service.DeepCopy()
+	updated.Annotations[constants.TIERS_ANNOTATION] = "public"
+	c.updateExposure(service, updated)
+	got = hosts()
+	if len(got) != 1 || got["app-public"] != "app.team.example.org" {
+		t.Errorf("updateExposure() ingresses = %v, want only the public one", got)
+	}
+}
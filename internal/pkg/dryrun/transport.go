@@ -0,0 +1,222 @@
+package dryrun
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/stakater/Xposer/internal/pkg/ownership"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+)
+
+// Dry run modes
+const (
+	ModeNone   = "none"
+	ModeClient = "client"
+	ModeServer = "server"
+)
+
+// exemptResources are written even in dry run mode, as they do not change what is exposed
+var exemptResources = map[string]bool{
+	"events":                   true,
+	"selfsubjectaccessreviews": true,
+}
+
+var verbs = map[string]string{
+	http.MethodPost:   "create",
+	http.MethodPut:    "update",
+	http.MethodPatch:  "patch",
+	http.MethodDelete: "delete",
+}
+
+// Change is a write to the API server which was not applied because of dry run
+type Change struct {
+	Verb      string
+	Resource  string
+	Namespace string
+	Name      string
+	// Owner is the namespace/name of the service the object was generated for, empty if unknown
+	Owner string
+}
+
+// Interceptor replaces every write of the clients using its transport by a Change. In client mode the request is
+// not sent at all, in server mode it is sent as a server side dry run, so that it is validated by admission
+type Interceptor struct {
+	mode     string
+	lock     sync.RWMutex
+	onChange func(Change)
+}
+
+// NewInterceptor creates an Interceptor for the given mode, which must not be ModeNone
+func NewInterceptor(mode string) *Interceptor {
+	return &Interceptor{
+		mode: mode,
+	}
+}
+
+// OnChange sets the function called for every intercepted change
+func (i *Interceptor) OnChange(onChange func(Change)) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.onChange = onChange
+}
+
+// Wrap wraps the given transport, it can be used as rest.Config.WrapTransport
+func (i *Interceptor) Wrap(rt http.RoundTripper) http.RoundTripper {
+	return &transport{
+		interceptor: i,
+		next:        rt,
+	}
+}
+
+// ValidateMode checks that the given dry run mode is known
+func ValidateMode(mode string) error {
+	if mode != ModeNone && mode != ModeClient && mode != ModeServer {
+		return fmt.Errorf("unknown dry run mode %q, must be one of: %v, %v, %v", mode, ModeNone, ModeClient, ModeServer)
+	}
+
+	return nil
+}
+
+// SupportsServerSide returns an error if the API server does not support server side dry run, which it would ignore
+// and apply the changes instead
+func SupportsServerSide(client discovery.ServerVersionInterface) error {
+	version, err := client.ServerVersion()
+	if err != nil {
+		return fmt.Errorf("can not get server version: %v", err)
+	}
+
+	major, _ := strconv.Atoi(strings.TrimRight(version.Major, "+"))
+	minor, _ := strconv.Atoi(strings.TrimRight(version.Minor, "+"))
+	if major < 1 || (major == 1 && minor < 13) {
+		return fmt.Errorf("server side dry run needs Kubernetes 1.13 or newer, server is %v.%v", version.Major, version.Minor)
+	}
+
+	return nil
+}
+
+type transport struct {
+	interceptor *Interceptor
+	next        http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	verb, isWrite := verbs[req.Method]
+	if !isWrite {
+		return t.next.RoundTrip(req)
+	}
+
+	namespace, resource, name := parsePath(req.URL.Path)
+	if exemptResources[resource] {
+		return t.next.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	change := Change{
+		Verb:      verb,
+		Resource:  resource,
+		Namespace: namespace,
+		Name:      name,
+	}
+	var object struct {
+		Metadata meta_v1.ObjectMeta `json:"metadata"`
+	}
+	if len(body) > 0 && json.Unmarshal(body, &object) == nil {
+		if change.Name == "" {
+			change.Name = object.Metadata.Name
+		}
+		if ownerNamespace, ownerName, ok := ownership.Owner(object.Metadata); ok {
+			change.Owner = ownerNamespace + "/" + ownerName
+		}
+	}
+
+	t.interceptor.lock.RLock()
+	onChange := t.interceptor.onChange
+	t.interceptor.lock.RUnlock()
+	if onChange != nil {
+		onChange(change)
+	}
+
+	if t.interceptor.mode == ModeServer {
+		dryRunReq := new(http.Request)
+		*dryRunReq = *req
+		dryRunURL := *req.URL
+		query := dryRunURL.Query()
+		query.Set("dryRun", "All")
+		dryRunURL.RawQuery = query.Encode()
+		dryRunReq.URL = &dryRunURL
+		dryRunReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+		dryRunReq.ContentLength = int64(len(body))
+		return t.next.RoundTrip(dryRunReq)
+	}
+
+	return fakeResponse(req, body), nil
+}
+
+// fakeResponse answers a write as if it succeeded, with the object which was sent
+func fakeResponse(req *http.Request, body []byte) *http.Response {
+	statusCode := http.StatusOK
+	switch req.Method {
+	case http.MethodPost:
+		statusCode = http.StatusCreated
+	case http.MethodDelete, http.MethodPatch:
+		body = []byte(`{"kind":"Status","apiVersion":"v1","status":"Success"}`)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         req.Proto,
+		ProtoMajor:    req.ProtoMajor,
+		ProtoMinor:    req.ProtoMinor,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// parsePath returns the namespace, resource and name of an API path like /api/v1/namespaces/ns/services/name or
+// /apis/group/version/namespaces/ns/ingresses/name
+func parsePath(path string) (namespace string, resource string, name string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) > 2 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) > 3 && parts[0] == "apis":
+		parts = parts[3:]
+	default:
+		return "", "", ""
+	}
+
+	if len(parts) > 2 && parts[0] == "namespaces" {
+		namespace = parts[1]
+		parts = parts[2:]
+	}
+	if len(parts) > 0 {
+		resource = parts[0]
+	}
+	if len(parts) > 1 {
+		name = parts[1]
+	}
+	if resource == "namespaces" && name != "" {
+		namespace = name
+	}
+
+	return namespace, resource, name
+}
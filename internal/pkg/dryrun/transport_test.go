@@ -0,0 +1,107 @@
+package dryrun
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+// recordingTransport records the requests which reach the API server
+type recordingTransport struct {
+	requests []*http.Request
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.requests = append(r.requests, req)
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader([]byte("{}"))), Request: req}, nil
+}
+
+func TestTransport(t *testing.T) {
+	ingress := `{"metadata":{"name":"app","labels":{"app.kubernetes.io/managed-by":"xposer"},"annotations":{"xposer.stakater.com/owner":"team/app"}}}`
+
+	tests := []struct {
+		name        string
+		mode        string
+		method      string
+		path        string
+		body        string
+		wantChange  *Change
+		wantQuery   string
+		wantForward bool
+	}{
+		{
+			name:        "reads should reach the server",
+			mode:        ModeClient,
+			method:      http.MethodGet,
+			path:        "/api/v1/namespaces/team/services",
+			wantForward: true,
+		},
+		{
+			name:        "events should reach the server",
+			mode:        ModeClient,
+			method:      http.MethodPost,
+			path:        "/api/v1/namespaces/team/events",
+			body:        `{"metadata":{"name":"event"}}`,
+			wantForward: true,
+		},
+		{
+			name:       "create should be intercepted in client mode",
+			mode:       ModeClient,
+			method:     http.MethodPost,
+			path:       "/apis/extensions/v1beta1/namespaces/team/ingresses",
+			body:       ingress,
+			wantChange: &Change{Verb: "create", Resource: "ingresses", Namespace: "team", Name: "app", Owner: "team/app"},
+		},
+		{
+			name:       "delete should be intercepted in client mode",
+			mode:       ModeClient,
+			method:     http.MethodDelete,
+			path:       "/api/v1/namespaces/team/configmaps/xposer",
+			wantChange: &Change{Verb: "delete", Resource: "configmaps", Namespace: "team", Name: "xposer"},
+		},
+		{
+			name:        "update should be sent as dry run in server mode",
+			mode:        ModeServer,
+			method:      http.MethodPut,
+			path:        "/apis/extensions/v1beta1/namespaces/team/ingresses/app",
+			body:        ingress,
+			wantChange:  &Change{Verb: "update", Resource: "ingresses", Namespace: "team", Name: "app", Owner: "team/app"},
+			wantQuery:   "dryRun=All",
+			wantForward: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &recordingTransport{}
+			interceptor := NewInterceptor(tt.mode)
+			var changes []Change
+			interceptor.OnChange(func(change Change) {
+				changes = append(changes, change)
+			})
+
+			req, _ := http.NewRequest(tt.method, "https://cluster"+tt.path, bytes.NewReader([]byte(tt.body)))
+			resp, err := interceptor.Wrap(next).RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip() error = %v", err)
+			}
+			if resp.StatusCode >= 300 {
+				t.Errorf("RoundTrip() status = %v", resp.StatusCode)
+			}
+
+			if forwarded := len(next.requests) > 0; forwarded != tt.wantForward {
+				t.Fatalf("RoundTrip() forwarded = %v, want %v", forwarded, tt.wantForward)
+			}
+			if tt.wantForward && next.requests[0].URL.RawQuery != tt.wantQuery {
+				t.Errorf("RoundTrip() query = %v, want %v", next.requests[0].URL.RawQuery, tt.wantQuery)
+			}
+
+			if tt.wantChange == nil && len(changes) > 0 {
+				t.Errorf("RoundTrip() changes = %v, want none", changes)
+			}
+			if tt.wantChange != nil && (len(changes) != 1 || changes[0] != *tt.wantChange) {
+				t.Errorf("RoundTrip() changes = %v, want %v", changes, *tt.wantChange)
+			}
+		})
+	}
+}
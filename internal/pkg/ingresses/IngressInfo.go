@@ -1,7 +1,7 @@
 package ingresses
 
 import (
-	"strings"
+	"fmt"
 
 	"github.com/fatih/structs"
 	"github.com/stakater/Xposer/internal/pkg/config"
@@ -21,15 +21,59 @@ type IngressInfo struct {
 	ServicePort           int
 	AddTLS                bool
 	SecretName            string
+	// Tier is the name of the tier the service is exposed on, see config.Tier
+	Tier string
 }
 
-func CreateIngressInfo(newServiceObject *v1.Service, configuration config.Configuration) IngressInfo {
-	splittedAnnotations := strings.Split(string(newServiceObject.ObjectMeta.Annotations[constants.FORWARD_ANNOTATION]), "\n")
-	forwardAnnotationsMap := make(map[string]string)
-	ingressConfig := structs.Map(configuration)
+// CreateIngressInfo renders everything needed to expose a service on the default tier, namespace can be nil if it is
+// not known. An error is returned if any of the templates can not be rendered, in which case the service must not be
+// exposed
+func CreateIngressInfo(newServiceObject *v1.Service, namespace *v1.Namespace, configuration config.Configuration) (IngressInfo, error) {
+	return CreateTierIngressInfo(newServiceObject, namespace, configuration, config.DefaultTier)
+}
 
-	// Overrides default annotains with annotations from new service object
-	ingressConfig = config.ReplaceDefaultConfigWithProvidedServiceConfig(ingressConfig, newServiceObject)
+// CreateIngressInfos renders the exposures of a service on every tier it is exposed on, see
+// config.Configuration.ServiceTiers. An error is returned if any of them can not be rendered
+func CreateIngressInfos(newServiceObject *v1.Service, namespace *v1.Namespace, configuration config.Configuration) ([]IngressInfo, error) {
+	tiers, err := configuration.ServiceTiers(newServiceObject)
+	if err != nil {
+		return nil, err
+	}
+
+	ingressInfos := []IngressInfo{}
+	for _, tier := range tiers {
+		ingressInfo, err := CreateTierIngressInfo(newServiceObject, namespace, configuration, tier)
+		if err != nil {
+			return nil, fmt.Errorf("tier %v: %v", tier, err)
+		}
+		ingressInfos = append(ingressInfos, ingressInfo)
+	}
+
+	return ingressInfos, nil
+}
+
+// CreateTierIngressInfo renders the exposure of a service on the named tier. The settings of the tier override the
+// configuration, and the config annotations of the service override both. Names rendered from
+// templates inherited from the configuration are suffixed with the tier, so that the objects of tiers do not collide
+func CreateTierIngressInfo(newServiceObject *v1.Service, namespace *v1.Namespace, configuration config.Configuration, tier string) (IngressInfo, error) {
+	// The first port of the service is exposed, e.g. a headless service may have none
+	if len(newServiceObject.Spec.Ports) == 0 {
+		return IngressInfo{}, fmt.Errorf("service has no ports to expose")
+	}
+
+	inheritsName, inheritsSecretName := configuration.InheritsNames(tier)
+	tierAnnotations := configuration.TierAnnotations(tier)
+	profileAnnotations, err := configuration.ProfileAnnotations(newServiceObject)
+	if err != nil {
+		return IngressInfo{}, err
+	}
+	forward, err := ParseForwardAnnotations(newServiceObject.Annotations[constants.FORWARD_ANNOTATION])
+	if err != nil {
+		return IngressInfo{}, err
+	}
+	configuration = configuration.ForTier(tier)
+
+	ingressConfig := overrideIngressConfig(newServiceObject, configuration)
 
 	// Adds "/" in URL Path, if user has entered path annotaion without "/"
 	ingressConfig = AppendSlashInPathAnnotationIfNotPresent(ingressConfig)
@@ -37,20 +81,75 @@ func CreateIngressInfo(newServiceObject *v1.Service, configuration config.Config
 	//	Removes the content after "/" from URL-Template, and if user has not specified path from annotation, use the content after "/" as URL-Path
 	ingressConfig = templates.FormatURLTemplateAndDeriveURLPath(ingressConfig)
 
-	// Creates a map of annotations to forward to Ingress
-	forwardAnnotationsMap = CreateForwardAnnotationsMap(splittedAnnotations)
+	// Creates a map of annotations to forward to Ingress, from the least to the most specific: default annotations,
+	// tier annotations, ingress class, profiles picked by the service, and the annotations of the service
+	forwardAnnotationsMap := make(map[string]string)
+	for key, value := range configuration.DefaultAnnotations {
+		forwardAnnotationsMap[key] = value
+	}
+	for key, value := range tierAnnotations {
+		forwardAnnotationsMap[key] = value
+	}
+	if configuration.IngressClass != "" {
+		forwardAnnotationsMap[constants.INGRESS_CLASS_ANNOTATION] = configuration.IngressClass
+	}
+	for key, value := range profileAnnotations {
+		forwardAnnotationsMap[key] = value
+	}
+	for key, value := range forward.Annotations {
+		forwardAnnotationsMap[key] = value
+	}
 
 	// Generates URL Templates to parse Xposer Specific Annotations
-	urlTemplate := templates.CreateUrlTemplate(newServiceObject.Name, newServiceObject.Namespace, ingressConfig[constants.DOMAIN].(string))
-	nameTemplate := templates.CreateNameTemplate(newServiceObject.Name, newServiceObject.Namespace)
+	variables := templates.CreateServiceVariables(newServiceObject, namespace, configuration.ClusterName)
+	urlTemplate := templates.CreateUrlTemplate(variables, ingressConfig[constants.DOMAIN].(string))
+	nameTemplate := templates.CreateNameTemplate(variables)
 
 	// Generate Secret Template to create Secrets
-	secretTemplate := templates.CreateSecretTemplate(newServiceObject.Name, newServiceObject.Namespace)
+	secretTemplate := templates.CreateSecretTemplate(variables)
+
+	parsedURL, err := templates.ParseIngressURLOrPathTemplate(constants.INGRESS_URL_TEMPLATE, ingressConfig[constants.INGRESS_URL_TEMPLATE].(string), urlTemplate)
+	if err != nil {
+		return IngressInfo{}, err
+	}
+
+	parsedURLPath, err := templates.ParseIngressURLOrPathTemplate(constants.INGRESS_URL_PATH, ingressConfig[constants.INGRESS_URL_PATH].(string), urlTemplate)
+	if err != nil {
+		return IngressInfo{}, err
+	}
 
-	parsedURL := templates.ParseIngressURLOrPathTemplate(ingressConfig[constants.INGRESS_URL_TEMPLATE].(string), urlTemplate)
-	parsedURLPath := templates.ParseIngressURLOrPathTemplate(ingressConfig[constants.INGRESS_URL_PATH].(string), urlTemplate)
-	parsedIngressName := templates.ParseIngressNameTemplate(ingressConfig[constants.INGRESS_NAME_TEMPLATE].(string), nameTemplate)
-	parsedSecret := templates.ParseIngressSecretTemplate(ingressConfig[constants.SECRET_NAME_TEMPLATE].(string), secretTemplate)
+	parsedIngressName, err := templates.ParseIngressNameTemplate(ingressConfig[constants.INGRESS_NAME_TEMPLATE].(string), nameTemplate)
+	if err != nil {
+		return IngressInfo{}, err
+	}
+
+	if inheritsName {
+		parsedIngressName += "-" + tier
+	}
+
+	// Rendered values are made valid DNS-1123 names and hosts, as the API rejects Ingresses and Routes with invalid ones
+	if configuration.NormalizeHost {
+		parsedURL = templates.NormalizeHost(parsedURL)
+	}
+	if configuration.NormalizeIngressName {
+		parsedIngressName = templates.NormalizeName(parsedIngressName)
+	}
+
+	// Without a secret name template certmanager derives the secret name from the ingress name
+	parsedSecret := constants.NO_SECRET
+	secretNameTemplate := ingressConfig[constants.SECRET_NAME_TEMPLATE].(string)
+	if secretNameTemplate != "" && secretNameTemplate != constants.NO_SECRET {
+		parsedSecret, err = templates.ParseIngressSecretTemplate(secretNameTemplate, secretTemplate)
+		if err != nil {
+			return IngressInfo{}, err
+		}
+		if inheritsSecretName {
+			parsedSecret += "-" + tier
+		}
+		if configuration.NormalizeTLSSecretName {
+			parsedSecret = templates.NormalizeName(parsedSecret)
+		}
+	}
 
 	return IngressInfo{
 		IngressName:           parsedIngressName,
@@ -62,5 +161,14 @@ func CreateIngressInfo(newServiceObject *v1.Service, configuration config.Config
 		ServicePort:           services.GetServicePortFromEvent(newServiceObject),
 		AddTLS:                ShouldAddTLS(ingressConfig, configuration.TLS),
 		SecretName:            parsedSecret,
-	}
+		Tier:                  tier,
+	}, nil
+}
+
+// overrideIngressConfig returns the configuration as a map, overridden with the config annotations of the service
+func overrideIngressConfig(newServiceObject *v1.Service, configuration config.Configuration) map[string]interface{} {
+	ingressConfig := structs.Map(configuration)
+
+	// Overrides default annotains with annotations from new service object
+	return config.ReplaceDefaultConfigWithProvidedServiceConfig(ingressConfig, newServiceObject)
 }
@@ -0,0 +1,130 @@
+package ingresses
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreateIngressInfosWithTiers(t *testing.T) {
+	enabled := true
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+	conf.TLSSecretNameTemplate = "{{.Service}}-tls"
+	conf.Tiers = map[string]config.Tier{
+		"internal": {
+			Domain:       "internal.example.com",
+			IngressClass: "nginx-internal",
+			Annotations:  map[string]string{"whitelist": "10.0.0.0/8", "proxy-body-size": "1m"},
+		},
+		"external": {
+			IngressNameTemplate: "{{.Service}}-public",
+			TLS:                 &enabled,
+		},
+	}
+
+	service := &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "app",
+			Namespace: "team",
+			Labels:    map[string]string{constants.EXPOSE: "true"},
+			Annotations: map[string]string{
+				constants.TIERS_ANNOTATION:   "default,internal,external",
+				constants.FORWARD_ANNOTATION: "proxy-body-size: 8m",
+			},
+		},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+	}
+
+	got, err := CreateIngressInfos(service, nil, conf)
+	if err != nil {
+		t.Fatalf("CreateIngressInfos() error = %v", err)
+	}
+
+	want := []IngressInfo{
+		{Tier: config.DefaultTier, IngressName: "app", IngressHost: "app.team.example.com", SecretName: "app-tls"},
+		{Tier: "internal", IngressName: "app-internal", IngressHost: "app.team.internal.example.com", SecretName: "app-tls-internal"},
+		{Tier: "external", IngressName: "app-public", IngressHost: "app.team.example.com", SecretName: "app-tls-external", AddTLS: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("CreateIngressInfos() = %v infos, want %v", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Tier != want[i].Tier || got[i].IngressName != want[i].IngressName || got[i].IngressHost != want[i].IngressHost ||
+			got[i].SecretName != want[i].SecretName || got[i].AddTLS != want[i].AddTLS {
+			t.Errorf("CreateIngressInfos()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// The annotations of the service override those of the tier
+	internal := got[1].ForwardAnnotationsMap
+	if internal[constants.INGRESS_CLASS_ANNOTATION] != "nginx-internal" || internal["whitelist"] != "10.0.0.0/8" || internal["proxy-body-size"] != "8m" {
+		t.Errorf("CreateIngressInfos() internal annotations = %v", internal)
+	}
+	if _, ok := got[0].ForwardAnnotationsMap["whitelist"]; ok {
+		t.Errorf("CreateIngressInfos() default annotations = %v, should not have the annotations of a tier", got[0].ForwardAnnotationsMap)
+	}
+}
+
+func TestCreateIngressInfoAnnotationPrecedence(t *testing.T) {
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+	conf.DefaultAnnotations = map[string]string{"issuer": "default", "body-size": "default", "whitelist": "default", "ssl": "default"}
+	conf.AnnotationProfiles = map[string]map[string]string{
+		"uploads": {"body-size": "profile"},
+		"office":  {"whitelist": "profile", "ssl": "profile"},
+	}
+	conf.Tiers = map[string]config.Tier{
+		"internal": {Annotations: map[string]string{"issuer": "tier", "body-size": "tier"}},
+	}
+
+	service := &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "app",
+			Namespace: "team",
+			Annotations: map[string]string{
+				constants.TIERS_ANNOTATION:               "internal",
+				constants.ANNOTATION_PROFILES_ANNOTATION: "uploads,office",
+				constants.FORWARD_ANNOTATION:             "ssl: service",
+			},
+		},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+	}
+
+	got, err := CreateIngressInfos(service, nil, conf)
+	if err != nil {
+		t.Fatalf("CreateIngressInfos() error = %v", err)
+	}
+
+	// Default annotations < tier annotations < profiles < annotations of the service
+	want := map[string]string{"issuer": "tier", "body-size": "profile", "whitelist": "profile", "ssl": "service"}
+	if len(got) != 1 || !reflect.DeepEqual(got[0].ForwardAnnotationsMap, want) {
+		t.Errorf("CreateIngressInfos() annotations = %v, want %v", got[0].ForwardAnnotationsMap, want)
+	}
+
+	service.Annotations[constants.ANNOTATION_PROFILES_ANNOTATION] = "unknown"
+	if _, err := CreateIngressInfos(service, nil, conf); err == nil {
+		t.Errorf("CreateIngressInfos() with an unknown profile should return an error")
+	}
+}
+
+func TestCreateIngressInfoWithoutPorts(t *testing.T) {
+	conf := config.DefaultConfiguration()
+	conf.Domain = "example.com"
+	service := &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "headless",
+			Namespace: "team",
+			Labels:    map[string]string{constants.EXPOSE: "true"},
+		},
+		Spec: v1.ServiceSpec{ClusterIP: v1.ClusterIPNone},
+	}
+
+	if _, err := CreateIngressInfo(service, nil, conf); err == nil {
+		t.Errorf("CreateIngressInfo() error = nil, want an error for a service without ports")
+	}
+}
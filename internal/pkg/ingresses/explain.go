@@ -0,0 +1,200 @@
+package ingresses
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/templates"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Setting is one of the effective settings used to expose a service
+type Setting struct {
+	Name     string        `json:"name"`
+	Source   config.Source `json:"source"`
+	Template string        `json:"template,omitempty"`
+	Value    string        `json:"value,omitempty"`
+	Detail   string        `json:"detail,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Explain lists every setting used to expose the given service on the named tier, with the source of its value and
+// its template before rendering. sources are the sources of the configuration, see config.Loader.Explain. The same
+// steps as in CreateTierIngressInfo are applied, but a template which can not be rendered is reported in its setting
+// instead of stopping at the first error
+func Explain(service *v1.Service, namespace *v1.Namespace, configuration config.Configuration, sources config.Sources, tier string) []Setting {
+	sources = sources.Copy()
+	clusterNameSource := sources[constants.CLUSTER_NAME]
+	inheritsName, inheritsSecretName := configuration.InheritsNames(tier)
+	sources.SetFromTier(configuration, tier)
+	configuration = configuration.ForTier(tier)
+	sources.SetFromAnnotations(service.ObjectMeta.Annotations, config.SourceService)
+
+	ingressConfig := overrideIngressConfig(service, configuration)
+	configuredURLTemplate := ingressConfig[constants.INGRESS_URL_TEMPLATE].(string)
+	configuredURLPath := ingressConfig[constants.INGRESS_URL_PATH].(string)
+
+	ingressConfig = AppendSlashInPathAnnotationIfNotPresent(ingressConfig)
+	ingressConfig = templates.FormatURLTemplateAndDeriveURLPath(ingressConfig)
+
+	variables := templates.CreateServiceVariables(service, namespace, configuration.ClusterName)
+	domain := ingressConfig[constants.DOMAIN].(string)
+	urlTemplate := templates.CreateUrlTemplate(variables, domain)
+
+	settings := []Setting{
+		explainTier(service, tier, configuration.TierLabelKey()),
+		{
+			Name:   constants.DOMAIN,
+			Source: sources[constants.DOMAIN],
+			Value:  domain,
+		},
+	}
+
+	// URL template
+	urlSetting := Setting{
+		Name:     constants.INGRESS_URL_TEMPLATE,
+		Source:   sources[constants.INGRESS_URL_TEMPLATE],
+		Template: ingressConfig[constants.INGRESS_URL_TEMPLATE].(string),
+	}
+	if strings.Contains(configuredURLTemplate, "/") {
+		urlSetting.Detail = fmt.Sprintf("configured as %q, the part after the first / is not part of the host", configuredURLTemplate)
+	}
+	setRendered(&urlSetting, func() (string, error) {
+		return templates.ParseIngressURLOrPathTemplate(constants.INGRESS_URL_TEMPLATE, urlSetting.Template, urlTemplate)
+	})
+	normalizeSetting(&urlSetting, configuration.NormalizeHost, templates.NormalizeHost)
+	settings = append(settings, urlSetting)
+
+	// URL path
+	pathSetting := Setting{
+		Name:     constants.INGRESS_URL_PATH,
+		Source:   sources[constants.INGRESS_URL_PATH],
+		Template: ingressConfig[constants.INGRESS_URL_PATH].(string),
+	}
+	if !strings.HasPrefix(configuredURLPath, "/") {
+		pathSetting.Detail = fmt.Sprintf("configured as %q, a / is prepended", configuredURLPath)
+	}
+	if strings.Contains(configuredURLTemplate, "/") && strings.TrimPrefix(configuredURLPath, "/") == "" {
+		pathSetting.Source = config.SourceDerived
+		pathSetting.Detail = fmt.Sprintf("taken from the part of %v after the first /", constants.INGRESS_URL_TEMPLATE)
+	}
+	setRendered(&pathSetting, func() (string, error) {
+		return templates.ParseIngressURLOrPathTemplate(constants.INGRESS_URL_PATH, pathSetting.Template, urlTemplate)
+	})
+	settings = append(settings, pathSetting)
+
+	// Ingress name
+	nameSetting := Setting{
+		Name:     constants.INGRESS_NAME_TEMPLATE,
+		Source:   sources[constants.INGRESS_NAME_TEMPLATE],
+		Template: ingressConfig[constants.INGRESS_NAME_TEMPLATE].(string),
+	}
+	setRendered(&nameSetting, func() (string, error) {
+		return templates.ParseIngressNameTemplate(nameSetting.Template, templates.CreateNameTemplate(variables))
+	})
+	if inheritsName {
+		suffixSetting(&nameSetting, tier)
+	}
+	normalizeSetting(&nameSetting, configuration.NormalizeIngressName, templates.NormalizeName)
+	settings = append(settings, nameSetting)
+
+	// TLS
+	settings = append(settings, Setting{
+		Name:   constants.TLS,
+		Source: sources[constants.TLS],
+		Value:  fmt.Sprintf("%v", ShouldAddTLS(ingressConfig, configuration.TLS)),
+	})
+
+	// Secret name
+	secretSetting := Setting{
+		Name:     constants.SECRET_NAME_TEMPLATE,
+		Source:   sources[constants.SECRET_NAME_TEMPLATE],
+		Template: ingressConfig[constants.SECRET_NAME_TEMPLATE].(string),
+	}
+	if secretSetting.Template == "" || secretSetting.Template == constants.NO_SECRET {
+		secretSetting.Value = constants.NO_SECRET
+		secretSetting.Detail = "certmanager derives the secret name from the ingress name"
+	} else {
+		setRendered(&secretSetting, func() (string, error) {
+			return templates.ParseIngressSecretTemplate(secretSetting.Template, templates.CreateSecretTemplate(variables))
+		})
+		if inheritsSecretName {
+			suffixSetting(&secretSetting, tier)
+		}
+		normalizeSetting(&secretSetting, configuration.NormalizeTLSSecretName, templates.NormalizeName)
+	}
+	settings = append(settings, secretSetting)
+
+	// The cluster name can only be configured for Xposer, not per namespace or service
+	clusterNameSetting := Setting{
+		Name:   constants.CLUSTER_NAME,
+		Source: clusterNameSource,
+		Value:  configuration.ClusterName,
+	}
+	if sources[constants.CLUSTER_NAME] != clusterNameSource {
+		clusterNameSetting.Detail = "can not be overridden by annotations, the annotation is ignored"
+	}
+	settings = append(settings, clusterNameSetting)
+
+	return settings
+}
+
+// explainTier tells why the service is exposed on the named tier
+func explainTier(service *v1.Service, tier string, tierLabel string) Setting {
+	setting := Setting{
+		Name:   "Tier",
+		Source: config.SourceDefault,
+		Value:  tier,
+	}
+	if strings.TrimSpace(service.Annotations[constants.TIERS_ANNOTATION]) != "" {
+		setting.Source = config.SourceService
+		setting.Detail = fmt.Sprintf("listed in annotation %v", constants.TIERS_ANNOTATION)
+	} else if tier != config.DefaultTier {
+		setting.Source = config.SourceDerived
+		setting.Detail = fmt.Sprintf("taken from the value of label %v", tierLabel)
+	}
+
+	return setting
+}
+
+// suffixSetting appends the tier to a rendered name whose template is inherited from the configuration, like
+// CreateTierIngressInfo, so that the objects of tiers do not collide
+func suffixSetting(setting *Setting, tier string) {
+	if setting.Error != "" {
+		return
+	}
+
+	setting.Value += "-" + tier
+	setting.Detail = "inherited from the configuration, suffixed with the tier"
+}
+
+func setRendered(setting *Setting, render func() (string, error)) {
+	value, err := render()
+	if err != nil {
+		setting.Error = err.Error()
+		return
+	}
+
+	setting.Value = value
+}
+
+// normalizeSetting applies the normalization of a rendered value like CreateIngressInfo, and tells when it changed it
+func normalizeSetting(setting *Setting, enabled bool, normalize func(string) string) {
+	if !enabled || setting.Error != "" {
+		return
+	}
+
+	normalized := normalize(setting.Value)
+	if normalized == setting.Value {
+		return
+	}
+
+	detail := fmt.Sprintf("rendered as %q, normalized to a valid DNS-1123 name", setting.Value)
+	if setting.Detail != "" {
+		detail = setting.Detail + "; " + detail
+	}
+	setting.Detail = detail
+	setting.Value = normalized
+}
@@ -0,0 +1,217 @@
+package ingresses
+
+import (
+	"testing"
+
+	"github.com/stakater/Xposer/internal/pkg/config"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExplain(t *testing.T) {
+	configuration := config.Configuration{
+		Domain:                 "stakater.com",
+		IngressURLTemplate:     "{{.Service}}.{{.Domain}}/api",
+		IngressURLPath:         "/",
+		IngressNameTemplate:    "{{.Service}}",
+		TLSSecretNameTemplate:  "{{.Service}}_TLS",
+		NormalizeTLSSecretName: true,
+	}
+	sources := config.Sources{
+		constants.DOMAIN:                config.SourceFile,
+		constants.INGRESS_URL_TEMPLATE:  config.SourceFile,
+		constants.INGRESS_URL_PATH:      config.SourceDefault,
+		constants.INGRESS_NAME_TEMPLATE: config.SourceDefault,
+		constants.TLS:                   config.SourceDefault,
+		constants.SECRET_NAME_TEMPLATE:  config.SourceDefault,
+		constants.CLUSTER_NAME:          config.SourceDefault,
+	}
+	service := &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "app",
+			Namespace: "team",
+			Annotations: map[string]string{
+				"config.xposer.stakater.com/IngressNameTemplate": "{{.Labels.missing}}",
+				"config.xposer.stakater.com/ClusterName":         "ignored",
+			},
+		},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+	}
+	namespace := &v1.Namespace{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "team",
+			Annotations: map[string]string{"config.xposer.stakater.com/TLS": "true"},
+		},
+	}
+
+	settings := make(map[string]Setting)
+	for _, setting := range Explain(service, namespace, configuration, sources, config.DefaultTier) {
+		settings[setting.Name] = setting
+	}
+
+	tests := []struct {
+		name       string
+		setting    string
+		wantSource config.Source
+		wantValue  string
+		wantErr    bool
+	}{
+		{
+			name:       "domain should come from the file",
+			setting:    constants.DOMAIN,
+			wantSource: config.SourceFile,
+			wantValue:  "stakater.com",
+		},
+		{
+			name:       "url template should be rendered without the path",
+			setting:    constants.INGRESS_URL_TEMPLATE,
+			wantSource: config.SourceFile,
+			wantValue:  "app.stakater.com",
+		},
+		{
+			name:       "path should be derived from the url template",
+			setting:    constants.INGRESS_URL_PATH,
+			wantSource: config.SourceDerived,
+			wantValue:  "/api",
+		},
+		{
+			name:       "name template error should be reported in its setting",
+			setting:    constants.INGRESS_NAME_TEMPLATE,
+			wantSource: config.SourceService,
+			wantErr:    true,
+		},
+		{
+			name:       "tls annotation of the namespace should be ignored",
+			setting:    constants.TLS,
+			wantSource: config.SourceDefault,
+			wantValue:  "false",
+		},
+		{
+			name:       "secret name should be normalized",
+			setting:    constants.SECRET_NAME_TEMPLATE,
+			wantSource: config.SourceDefault,
+			wantValue:  "app-tls",
+		},
+		{
+			name:       "cluster name annotation should be ignored",
+			setting:    constants.CLUSTER_NAME,
+			wantSource: config.SourceDefault,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := settings[tt.setting]
+			if got.Source != tt.wantSource {
+				t.Errorf("Explain() %v source = %v, want %v", tt.setting, got.Source, tt.wantSource)
+			}
+			if got.Value != tt.wantValue {
+				t.Errorf("Explain() %v value = %v, want %v", tt.setting, got.Value, tt.wantValue)
+			}
+			if (got.Error != "") != tt.wantErr {
+				t.Errorf("Explain() %v error = %v, wantErr %v", tt.setting, got.Error, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExplainTier(t *testing.T) {
+	enabled := true
+	configuration := config.Configuration{
+		Domain:                "stakater.com",
+		IngressURLTemplate:    "{{.Service}}.{{.Domain}}",
+		IngressURLPath:        "/",
+		IngressNameTemplate:   "{{.Service}}",
+		TLSSecretNameTemplate: "{{.Service}}-cert",
+		Tiers: map[string]config.Tier{
+			"internal": {Domain: "internal.stakater.com", TLS: &enabled},
+		},
+	}
+	sources := config.Sources{
+		constants.DOMAIN:                config.SourceFile,
+		constants.INGRESS_URL_TEMPLATE:  config.SourceFile,
+		constants.INGRESS_URL_PATH:      config.SourceDefault,
+		constants.INGRESS_NAME_TEMPLATE: config.SourceDefault,
+		constants.TLS:                   config.SourceDefault,
+		constants.SECRET_NAME_TEMPLATE:  config.SourceDefault,
+		constants.CLUSTER_NAME:          config.SourceDefault,
+	}
+	service := &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "app",
+			Namespace:   "team",
+			Annotations: map[string]string{constants.TIERS_ANNOTATION: "default,internal"},
+		},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+	}
+
+	settings := make(map[string]Setting)
+	for _, setting := range Explain(service, nil, configuration, sources, "internal") {
+		settings[setting.Name] = setting
+	}
+
+	tests := []struct {
+		name       string
+		setting    string
+		wantSource config.Source
+		wantValue  string
+	}{
+		{
+			name:       "tier should come from the service annotation",
+			setting:    "Tier",
+			wantSource: config.SourceService,
+			wantValue:  "internal",
+		},
+		{
+			name:       "domain should come from the tier",
+			setting:    constants.DOMAIN,
+			wantSource: config.SourceTier,
+			wantValue:  "internal.stakater.com",
+		},
+		{
+			name:       "url should be rendered with the domain of the tier",
+			setting:    constants.INGRESS_URL_TEMPLATE,
+			wantSource: config.SourceFile,
+			wantValue:  "app.internal.stakater.com",
+		},
+		{
+			name:       "tls should come from the tier",
+			setting:    constants.TLS,
+			wantSource: config.SourceTier,
+			wantValue:  "true",
+		},
+		{
+			name:       "inherited ingress name should be suffixed with the tier",
+			setting:    constants.INGRESS_NAME_TEMPLATE,
+			wantSource: config.SourceDefault,
+			wantValue:  "app-internal",
+		},
+		{
+			name:       "inherited secret name should be suffixed with the tier",
+			setting:    constants.SECRET_NAME_TEMPLATE,
+			wantSource: config.SourceDefault,
+			wantValue:  "app-cert-internal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := settings[tt.setting]
+			if got.Source != tt.wantSource {
+				t.Errorf("Explain() %v source = %v, want %v", tt.setting, got.Source, tt.wantSource)
+			}
+			if got.Value != tt.wantValue {
+				t.Errorf("Explain() %v value = %v, want %v", tt.setting, got.Value, tt.wantValue)
+			}
+		})
+	}
+
+	// The explained names must match those Xposer renders
+	ingressInfo, err := CreateTierIngressInfo(service, nil, configuration, "internal")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if settings[constants.INGRESS_NAME_TEMPLATE].Value != ingressInfo.IngressName || settings[constants.SECRET_NAME_TEMPLATE].Value != ingressInfo.SecretName {
+		t.Errorf("Explain() names = %v and %v, rendered as %v and %v", settings[constants.INGRESS_NAME_TEMPLATE].Value,
+			settings[constants.SECRET_NAME_TEMPLATE].Value, ingressInfo.IngressName, ingressInfo.SecretName)
+	}
+}
@@ -0,0 +1,133 @@
+package ingresses
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	yaml "gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Formats of the forward annotation of a service
+const (
+	ForwardFormatLegacy = "legacy"
+	ForwardFormatYAML   = "yaml"
+	ForwardFormatJSON   = "json"
+)
+
+// controlKeys are the keys of the forward annotation which configure Xposer itself, they are never forwarded
+var controlKeys = map[string]bool{
+	constants.EXPOSE_INGRESS_URL: true,
+}
+
+// ForwardAnnotations holds the annotations a service forwards to the objects generated for it, and the control keys
+// set in the same annotation, e.g. exposeIngressUrl
+type ForwardAnnotations struct {
+	Annotations map[string]string
+	Control     map[string]string
+	Format      string
+}
+
+// yamlDocumentMarker starts a forward annotation written as a YAML mapping
+const yamlDocumentMarker = "---"
+
+// ParseForwardAnnotations parses the value of the forward annotation. A value starting with { is a JSON object and a
+// value starting with a --- line is a YAML mapping, which supports quoted and multi-line values. Any other value is
+// one key: value pair per line, split on the first colon, and kept as written, e.g. with a # or quotes
+func ParseForwardAnnotations(value string) (ForwardAnnotations, error) {
+	forward := ForwardAnnotations{
+		Annotations: make(map[string]string),
+		Control:     make(map[string]string),
+		Format:      ForwardFormatLegacy,
+	}
+
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return forward, nil
+	}
+
+	var parsed map[string]string
+	if strings.HasPrefix(trimmed, "{") {
+		var err error
+		parsed, err = parseJSONAnnotations(trimmed)
+		if err != nil {
+			return ForwardAnnotations{}, fmt.Errorf("invalid JSON in annotation %v: %v", constants.FORWARD_ANNOTATION, err)
+		}
+		forward.Format = ForwardFormatJSON
+	} else if isYAMLDocument(trimmed) {
+		if err := yaml.Unmarshal([]byte(trimmed), &parsed); err != nil {
+			return ForwardAnnotations{}, fmt.Errorf("invalid YAML in annotation %v: %v", constants.FORWARD_ANNOTATION, err)
+		}
+		forward.Format = ForwardFormatYAML
+	} else {
+		parsed = CreateForwardAnnotationsMap(strings.Split(value, "\n"))
+	}
+
+	for key, annotationValue := range parsed {
+		if controlKeys[key] {
+			forward.Control[key] = annotationValue
+		} else {
+			forward.Annotations[key] = annotationValue
+		}
+	}
+
+	return forward, nil
+}
+
+// isYAMLDocument returns true if the first line of a trimmed value is the --- marker of a YAML document
+func isYAMLDocument(value string) bool {
+	firstLine := strings.SplitN(value, "\n", 2)[0]
+	return strings.TrimSpace(firstLine) == yamlDocumentMarker
+}
+
+// parseJSONAnnotations decodes a JSON object whose values are strings, numbers, booleans or null, as annotation values
+// can only be strings
+func parseJSONAnnotations(value string) (map[string]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.UseNumber()
+
+	var document map[string]interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	annotations := make(map[string]string)
+	for key, documentValue := range document {
+		switch typed := documentValue.(type) {
+		case string:
+			annotations[key] = typed
+		case json.Number:
+			annotations[key] = typed.String()
+		case bool:
+			annotations[key] = strconv.FormatBool(typed)
+		case nil:
+			annotations[key] = ""
+		default:
+			return nil, fmt.Errorf("value of %q must be a string", key)
+		}
+	}
+
+	return annotations, nil
+}
+
+// getForwardAnnotations parses the forward annotation of a service, an invalid one is logged and forwards nothing
+func getForwardAnnotations(service *v1.Service) ForwardAnnotations {
+	forward, err := ParseForwardAnnotations(service.ObjectMeta.Annotations[constants.FORWARD_ANNOTATION])
+	if err != nil {
+		logrus.Warningf("Service: %v in namespace: %v has an %v", service.Name, service.Namespace, err)
+		return ForwardAnnotations{Annotations: map[string]string{}, Control: map[string]string{}}
+	}
+
+	return forward
+}
+
+// GetExposeIngressURL returns where the URL of a service is published, locally, globally or nowhere if empty, as set
+// with the exposeIngressUrl control key of its forward annotation
+func GetExposeIngressURL(service *v1.Service) string {
+	return getForwardAnnotations(service).Control[constants.EXPOSE_INGRESS_URL]
+}
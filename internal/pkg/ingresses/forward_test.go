@@ -0,0 +1,105 @@
+package ingresses
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseForwardAnnotations(t *testing.T) {
+	tests := []struct {
+		name            string
+		value           string
+		wantFormat      string
+		wantAnnotations map[string]string
+		wantControl     map[string]string
+		wantErr         bool
+	}{
+		{
+			name:            "empty annotation should forward nothing",
+			value:           "",
+			wantFormat:      ForwardFormatLegacy,
+			wantAnnotations: map[string]string{},
+			wantControl:     map[string]string{},
+		},
+		{
+			name:            "legacy lines should be split on the first colon",
+			value:           "nginx.ingress.kubernetes.io/configuration-snippet: more_set_headers \"X-Frame: deny\";\nexposeIngressUrl: globally",
+			wantFormat:      ForwardFormatLegacy,
+			wantAnnotations: map[string]string{"nginx.ingress.kubernetes.io/configuration-snippet": "more_set_headers \"X-Frame: deny\";"},
+			wantControl:     map[string]string{"exposeIngressUrl": "globally"},
+		},
+		{
+			name:       "yaml should support multi-line values",
+			value:      "---\nnginx.ingress.kubernetes.io/configuration-snippet: |\n  more_set_headers \"X-Frame: deny\";\n  more_set_headers \"X-Xss: 1\";\nnginx.ingress.kubernetes.io/proxy-body-size: \"0050\"\nexposeIngressUrl: locally\n",
+			wantFormat: ForwardFormatYAML,
+			wantAnnotations: map[string]string{
+				"nginx.ingress.kubernetes.io/configuration-snippet": "more_set_headers \"X-Frame: deny\";\nmore_set_headers \"X-Xss: 1\";\n",
+				"nginx.ingress.kubernetes.io/proxy-body-size":       "0050",
+			},
+			wantControl: map[string]string{"exposeIngressUrl": "locally"},
+		},
+		{
+			name:            "legacy values should keep a # which is not a yaml comment",
+			value:           "a: b #c\nnginx.ingress.kubernetes.io/rewrite-target: /#/app",
+			wantFormat:      ForwardFormatLegacy,
+			wantAnnotations: map[string]string{"a": "b #c", "nginx.ingress.kubernetes.io/rewrite-target": "/#/app"},
+			wantControl:     map[string]string{},
+		},
+		{
+			name:            "legacy values should keep their quotes",
+			value:           "nginx.ingress.kubernetes.io/proxy-body-size: \"50m\"",
+			wantFormat:      ForwardFormatLegacy,
+			wantAnnotations: map[string]string{"nginx.ingress.kubernetes.io/proxy-body-size": "\"50m\""},
+			wantControl:     map[string]string{},
+		},
+		{
+			name:            "yaml document should drop comments",
+			value:           "---\na: b #c\n",
+			wantFormat:      ForwardFormatYAML,
+			wantAnnotations: map[string]string{"a": "b"},
+			wantControl:     map[string]string{},
+		},
+		{
+			name:    "invalid yaml document should be an error",
+			value:   "---\na: [b\n",
+			wantErr: true,
+		},
+		{
+			name:            "json should be detected",
+			value:           `{"nginx.ingress.kubernetes.io/server-snippet": "location /a {\n  deny all;\n}", "ssl-redirect": true, "replicas": 2}`,
+			wantFormat:      ForwardFormatJSON,
+			wantAnnotations: map[string]string{"nginx.ingress.kubernetes.io/server-snippet": "location /a {\n  deny all;\n}", "ssl-redirect": "true", "replicas": "2"},
+			wantControl:     map[string]string{},
+		},
+		{
+			name:    "json with nested values should be an error",
+			value:   `{"a": {"b": "c"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json should be an error",
+			value:   `{"a": "b"`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseForwardAnnotations(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseForwardAnnotations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Format != tt.wantFormat {
+				t.Errorf("ParseForwardAnnotations() format = %v, want %v", got.Format, tt.wantFormat)
+			}
+			if !reflect.DeepEqual(got.Annotations, tt.wantAnnotations) {
+				t.Errorf("ParseForwardAnnotations() annotations = %q, want %q", got.Annotations, tt.wantAnnotations)
+			}
+			if !reflect.DeepEqual(got.Control, tt.wantControl) {
+				t.Errorf("ParseForwardAnnotations() control = %v, want %v", got.Control, tt.wantControl)
+			}
+		})
+	}
+}
@@ -1,17 +1,20 @@
 package ingresses
 
 import (
+	"sort"
 	"strconv"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stakater/Xposer/internal/pkg/constants"
+	"github.com/stakater/Xposer/internal/pkg/ownership"
 	"k8s.io/api/extensions/v1beta1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func CreateFromIngressInfo(ingresInfo IngressInfo) *v1beta1.Ingress {
-	return &v1beta1.Ingress{
+	ingress := &v1beta1.Ingress{
 		ObjectMeta: meta_v1.ObjectMeta{
 			Name:        ingresInfo.IngressName,
 			Namespace:   ingresInfo.Namespace,
@@ -42,6 +45,30 @@ func CreateFromIngressInfo(ingresInfo IngressInfo) *v1beta1.Ingress {
 			},
 		},
 	}
+
+	// Marks the Ingress as generated by Xposer for the service
+	ownership.Mark(&ingress.ObjectMeta, ingresInfo.Namespace, ingresInfo.ServiceName)
+	ownership.RecordIdentity(&ingress.ObjectMeta, "Ingress")
+
+	return ingress
+}
+
+// CreateWithTLSFromIngressInfo creates the Ingress for the given info, including the TLS section if TLS is enabled
+func CreateWithTLSFromIngressInfo(ingressInfo IngressInfo) *v1beta1.Ingress {
+	ingress := CreateFromIngressInfo(ingressInfo)
+
+	// Adds TLS for cert-manager if specified via annotations
+	if ingressInfo.AddTLS == true {
+		if ingressInfo.SecretName != constants.NO_SECRET {
+			logrus.Info("Service contain TLS annotation,Generating from template")
+			AddTLSInfoTemplate(ingress, ingressInfo.SecretName, ingressInfo.IngressHost)
+		} else {
+			logrus.Info("Service contain TLS annotation, so automatically generating a TLS certificate via certmanager")
+			AddTLSInfo(ingress, ingressInfo.IngressName, ingressInfo.IngressHost)
+		}
+	}
+
+	return ingress
 }
 
 func IsEmpty(ingress v1beta1.Ingress) bool {
@@ -66,6 +93,26 @@ func GetFromListMatchingGivenServiceName(ingressList *v1beta1.IngressList, servi
 	return matchedIngress
 }
 
+// TargetsService returns true if the default backend or any path of the Ingress routes to the given service
+func TargetsService(ingress *v1beta1.Ingress, serviceName string) bool {
+	if ingress.Spec.Backend != nil && ingress.Spec.Backend.ServiceName == serviceName {
+		return true
+	}
+
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.ServiceName == serviceName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func AddTLSInfo(ingress *v1beta1.Ingress, ingressName string, ingressHost string) {
 	ingress.Spec.TLS = []v1beta1.IngressTLS{
 		v1beta1.IngressTLS{
@@ -102,3 +149,40 @@ func ShouldAddTLS(ingressConfig map[string]interface{}, defaultTLS bool) bool {
 
 	return false
 }
+
+// Diff returns the fields of the actual Ingress which differ from the desired one. Annotations added by others are
+// ignored, only annotations of the desired Ingress are compared
+func Diff(desired *v1beta1.Ingress, actual *v1beta1.Ingress) []string {
+	var drifted []string
+
+	if !apiequality.Semantic.DeepEqual(desired.Spec.Backend, actual.Spec.Backend) {
+		drifted = append(drifted, "spec.backend")
+	}
+	if !apiequality.Semantic.DeepEqual(desired.Spec.Rules, actual.Spec.Rules) {
+		drifted = append(drifted, "spec.rules")
+	}
+	if !apiequality.Semantic.DeepEqual(desired.Spec.TLS, actual.Spec.TLS) {
+		drifted = append(drifted, "spec.tls")
+	}
+
+	return append(drifted, DiffAnnotations(desired.Annotations, actual.Annotations)...)
+}
+
+// DiffAnnotations returns the annotations which are desired but missing or different in the actual annotations
+func DiffAnnotations(desired map[string]string, actual map[string]string) []string {
+	var drifted []string
+
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if value, ok := actual[key]; !ok || value != desired[key] {
+			drifted = append(drifted, "metadata.annotations["+key+"]")
+		}
+	}
+
+	return drifted
+}
@@ -64,7 +64,7 @@ func TestAddTLSInfo(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			AddTLSInfo(tt.args.ingress, tt.args.ingressName, tt.args.ingressHost)
 			if len(tt.args.ingress.Spec.TLS) < 1 {
-				t.Errorf("TLS Not added to ingress = %v, want %v", tt.args.ingress)
+				t.Errorf("TLS Not added to ingress = %v, want %v", tt.args.ingress, tt.modified)
 			}
 		})
 	}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/stakater/Xposer/internal/pkg/constants"
+	v1 "k8s.io/api/core/v1"
 )
 
 /*
@@ -19,7 +20,15 @@ func AppendSlashInPathAnnotationIfNotPresent(currentAnnotations map[string]inter
 }
 
 /*
-	Generate a map of annotations to forward to Ingress
+	Generate the map of annotations to forward to Ingress from the forward annotation of the given service, without
+	the control keys of Xposer, see ParseForwardAnnotations
+*/
+func GetForwardAnnotationsMap(service *v1.Service) map[string]string {
+	return getForwardAnnotations(service).Annotations
+}
+
+/*
+	Generate a map of annotations to forward to Ingress from lines in the legacy format, key: value
 */
 func CreateForwardAnnotationsMap(splittedAnnotations []string) map[string]string {
 	forwardAnnotationsMap := make(map[string]string)
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// DryRunChanges counts the changes which were not applied because Xposer runs in dry run mode
+	DryRunChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "xposer",
+		Name:      "dry_run_changes_total",
+		Help:      "Number of changes which were not applied because of dry run, by verb and resource",
+	}, []string{"verb", "resource"})
+
+	// DriftDetected counts the generated objects found changed or deleted by someone else
+	DriftDetected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "xposer",
+		Name:      "drift_detected_total",
+		Help:      "Number of generated objects found changed or deleted by someone else, by kind and applied policy",
+	}, []string{"kind", "policy"})
+
+	// HostConflicts is 1 for every service which is not exposed because its host and path are claimed by another object
+	HostConflicts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "xposer",
+		Name:      "host_conflicts",
+		Help:      "Services which are not exposed because their host and path are already claimed, by namespace and service",
+	}, []string{"namespace", "service"})
+)
+
+func init() {
+	prometheus.MustRegister(DryRunChanges)
+	prometheus.MustRegister(DriftDetected)
+	prometheus.MustRegister(HostConflicts)
+}
@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+// Serve exposes the registered metrics on /metrics of the given address, together with the given debug handlers
+// by path. It blocks until the server fails
+func Serve(address string, debugHandlers map[string]http.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	for path, handler := range debugHandlers {
+		mux.Handle(path, handler)
+	}
+
+	logrus.Infof("Serving metrics on: %v", address)
+	err := http.ListenAndServe(address, mux)
+	if err != nil {
+		logrus.Errorf("Metrics server stopped with error: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package ownership
+
+import (
+	"strings"
+
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Selector selects the objects managed by Xposer
+func Selector() string {
+	return constants.MANAGED_BY_LABEL + "=" + constants.CONTROLLER_NAME
+}
+
+// Mark labels the object as managed by Xposer, and records the service it was generated for. The labels and
+// annotations are copied, as they are often shared with the IngressInfo
+func Mark(objectMeta *meta_v1.ObjectMeta, serviceNamespace string, serviceName string) {
+	MarkManaged(objectMeta)
+
+	annotations := make(map[string]string, len(objectMeta.Annotations)+1)
+	for key, value := range objectMeta.Annotations {
+		annotations[key] = value
+	}
+	annotations[constants.OWNER_ANNOTATION] = serviceNamespace + "/" + serviceName
+	objectMeta.Annotations = annotations
+}
+
+// MarkManaged labels the object as managed by Xposer, for objects like the xposer configmap which are shared by
+// many services
+func MarkManaged(objectMeta *meta_v1.ObjectMeta) {
+	labels := make(map[string]string, len(objectMeta.Labels)+1)
+	for key, value := range objectMeta.Labels {
+		labels[key] = value
+	}
+	labels[constants.MANAGED_BY_LABEL] = constants.CONTROLLER_NAME
+	objectMeta.Labels = labels
+}
+
+// RecordIdentity records the identity of an object marked with Mark on it, so that an object generated before can
+// be told apart from the one the service generates now
+func RecordIdentity(objectMeta *meta_v1.ObjectMeta, kind string) {
+	objectMeta.Annotations[constants.IDENTITY_ANNOTATION] = Identity(kind, *objectMeta)
+}
+
+// Identity returns the identity of a generated object, e.g. Ingress/team/app
+func Identity(kind string, objectMeta meta_v1.ObjectMeta) string {
+	return kind + "/" + objectMeta.Namespace + "/" + objectMeta.Name
+}
+
+// AppliedIdentity returns the identity recorded on a generated object, objects generated before identities were
+// recorded are identified by their kind, namespace and name
+func AppliedIdentity(kind string, objectMeta meta_v1.ObjectMeta) string {
+	if identity := objectMeta.Annotations[constants.IDENTITY_ANNOTATION]; identity != "" {
+		return identity
+	}
+
+	return Identity(kind, objectMeta)
+}
+
+// IsManaged returns true if the object is labeled as managed by Xposer
+func IsManaged(objectMeta meta_v1.ObjectMeta) bool {
+	return objectMeta.Labels[constants.MANAGED_BY_LABEL] == constants.CONTROLLER_NAME
+}
+
+// Owner returns the namespace and name of the service the object was generated for, ok is false if the object is
+// not managed by Xposer or has no owner
+func Owner(objectMeta meta_v1.ObjectMeta) (namespace string, name string, ok bool) {
+	if !IsManaged(objectMeta) {
+		return "", "", false
+	}
+
+	owner := strings.SplitN(objectMeta.Annotations[constants.OWNER_ANNOTATION], "/", 2)
+	if len(owner) != 2 || owner[0] == "" || owner[1] == "" {
+		return "", "", false
+	}
+
+	return owner[0], owner[1], true
+}
@@ -0,0 +1,52 @@
+package ownership
+
+import (
+	"testing"
+
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOwner(t *testing.T) {
+	marked := meta_v1.ObjectMeta{Annotations: map[string]string{"forwarded": "value"}}
+	Mark(&marked, "team", "app")
+
+	tests := []struct {
+		name          string
+		objectMeta    meta_v1.ObjectMeta
+		wantNamespace string
+		wantName      string
+		wantOk        bool
+	}{
+		{
+			name:          "marked object should have the service as owner",
+			objectMeta:    marked,
+			wantNamespace: "team",
+			wantName:      "app",
+			wantOk:        true,
+		},
+		{
+			name:       "object without label should have no owner",
+			objectMeta: meta_v1.ObjectMeta{Annotations: map[string]string{constants.OWNER_ANNOTATION: "team/app"}},
+		},
+		{
+			name: "object with invalid owner should have no owner",
+			objectMeta: meta_v1.ObjectMeta{
+				Labels:      map[string]string{constants.MANAGED_BY_LABEL: constants.CONTROLLER_NAME},
+				Annotations: map[string]string{constants.OWNER_ANNOTATION: "app"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace, name, ok := Owner(tt.objectMeta)
+			if namespace != tt.wantNamespace || name != tt.wantName || ok != tt.wantOk {
+				t.Errorf("Owner() = %v, %v, %v, want %v, %v, %v", namespace, name, ok, tt.wantNamespace, tt.wantName, tt.wantOk)
+			}
+		})
+	}
+
+	if marked.Annotations["forwarded"] != "value" {
+		t.Errorf("Mark() should keep existing annotations")
+	}
+}
@@ -0,0 +1,135 @@
+package permissions
+
+import (
+	"fmt"
+
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Permission is a verb on a resource which Xposer needs. An empty namespace means all namespaces
+type Permission struct {
+	Group     string
+	Resource  string
+	Verb      string
+	Namespace string
+	// Optional permissions are only needed by some features, Reason tells which
+	Optional bool
+	Reason   string
+}
+
+// String describes the permission like an RBAC rule
+func (p Permission) String() string {
+	resource := p.Resource
+	if p.Group != "" {
+		resource = p.Resource + "." + p.Group
+	}
+
+	// Namespaces are not namespaced themselves
+	namespace := "in all namespaces"
+	if p.Resource == "namespaces" {
+		return fmt.Sprintf("%v %v", p.Verb, resource)
+	} else if p.Namespace != "" {
+		namespace = "in namespace " + p.Namespace
+	}
+
+	return fmt.Sprintf("%v %v %v", p.Verb, resource, namespace)
+}
+
+// Result is the outcome of checking a permission
+type Result struct {
+	Permission
+	Allowed bool
+	Error   string
+}
+
+// Required returns the permissions Xposer needs to watch services in the given namespace, all namespaces if empty,
+// on the given cluster type. Publishing globally makes the permissions to write xposer configmaps in all namespaces
+// required, as exposed services use exposeIngressUrl: globally then
+func Required(namespace string, clusterType string, publishGlobally bool) []Permission {
+	permissions := []Permission{}
+	add := func(group string, resource string, verbs []string, permissionNamespace string, optional bool, reason string) {
+		for _, verb := range verbs {
+			permissions = append(permissions, Permission{
+				Group:     group,
+				Resource:  resource,
+				Verb:      verb,
+				Namespace: permissionNamespace,
+				Optional:  optional,
+				Reason:    reason,
+			})
+		}
+	}
+
+	add("", "services", []string{"get", "list", "watch"}, namespace, false, "watch services to expose")
+	if clusterType == constants.OPENSHIFT {
+		add("route.openshift.io", "routes", []string{"get", "list", "watch", "create", "patch", "delete"}, namespace, false, "manage routes")
+	} else {
+		add("extensions", "ingresses", []string{"get", "list", "watch", "create", "patch", "delete"}, namespace, false, "manage ingresses")
+	}
+	add("", "events", []string{"create", "patch"}, namespace, false, "record events on services")
+	add("", "services", []string{"patch"}, namespace, true, "status annotation of services with host conflicts")
+	globalConfigMapReason := "exposeIngressUrl: locally or globally"
+	if namespace != "" {
+		add("", "configmaps", []string{"get", "create", "update"}, namespace, true, "exposeIngressUrl: locally")
+		globalConfigMapReason = "exposeIngressUrl: globally"
+	}
+
+	// Publishing globally writes to every namespace, even when only one namespace is watched
+	add("", "namespaces", []string{"list"}, "", !publishGlobally, "exposeIngressUrl: globally")
+	add("", "configmaps", []string{"get", "create", "update"}, "", !publishGlobally, globalConfigMapReason)
+	add("", "namespaces", []string{"get"}, "", true, "namespace labels and annotations in templates")
+
+	// Without them, namespaces and xposer configmaps are read from the API server on every service event
+	add("", "namespaces", []string{"watch"}, "", true, "caching namespaces")
+	add("", "configmaps", []string{"list", "watch"}, "", true, "caching xposer configmaps")
+	if namespace != "" {
+		add("", "configmaps", []string{"list", "watch"}, namespace, true, "caching xposer configmaps")
+	}
+
+	return permissions
+}
+
+// Check asks the API server whether the current user has each of the given permissions
+func Check(clientset kubernetes.Interface, permissions []Permission) ([]Result, error) {
+	results := []Result{}
+
+	for _, permission := range permissions {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: permission.Namespace,
+					Verb:      permission.Verb,
+					Group:     permission.Group,
+					Resource:  permission.Resource,
+				},
+			},
+		}
+
+		response, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(review)
+		if err != nil {
+			return nil, fmt.Errorf("can not review access to %v: %v", permission, err)
+		}
+
+		results = append(results, Result{
+			Permission: permission,
+			Allowed:    response.Status.Allowed,
+			Error:      response.Status.EvaluationError,
+		})
+	}
+
+	return results, nil
+}
+
+// Missing returns the results which are not allowed
+func Missing(results []Result) []Result {
+	missing := []Result{}
+	for _, result := range results {
+		if !result.Allowed {
+			missing = append(missing, result)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,83 @@
+package permissions
+
+import (
+	"testing"
+
+	"github.com/stakater/Xposer/internal/pkg/constants"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCheck(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	// Only allows access in the watched namespace, like a Role
+	clientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = review.Spec.ResourceAttributes.Namespace == "team"
+		return true, review, nil
+	})
+
+	tests := []struct {
+		name            string
+		namespace       string
+		clusterType     string
+		publishGlobally bool
+		wantMissing     int
+		wantRequired    int
+		wantRequiredRes string
+	}{
+		{
+			name:        "role should only miss global permissions",
+			namespace:   "team",
+			clusterType: constants.KUBERNETES,
+			wantMissing: 8,
+		},
+		{
+			name:            "role should miss required global permissions when publishing globally",
+			namespace:       "team",
+			clusterType:     constants.KUBERNETES,
+			publishGlobally: true,
+			wantMissing:     8,
+			wantRequired:    4,
+			wantRequiredRes: "configmaps",
+		},
+		{
+			name:            "role should miss everything when watching all namespaces",
+			namespace:       "",
+			clusterType:     constants.OPENSHIFT,
+			wantMissing:     20,
+			wantRequired:    11,
+			wantRequiredRes: "routes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Check(clientset, Required(tt.namespace, tt.clusterType, tt.publishGlobally))
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+
+			missing := Missing(results)
+			if len(missing) != tt.wantMissing {
+				t.Errorf("Missing() = %v, want %d permissions", missing, tt.wantMissing)
+			}
+
+			required := 0
+			foundResource := tt.wantRequiredRes == ""
+			for _, result := range missing {
+				if !result.Optional {
+					required++
+					foundResource = foundResource || result.Resource == tt.wantRequiredRes
+				}
+			}
+			if required != tt.wantRequired {
+				t.Errorf("Missing() has %d required permissions, want %d", required, tt.wantRequired)
+			}
+			if !foundResource {
+				t.Errorf("Missing() should contain %v", tt.wantRequiredRes)
+			}
+		})
+	}
+}
@@ -2,13 +2,16 @@ package routes
 
 import (
 	osV1 "github.com/openshift/api/route/v1"
+	"github.com/stakater/Xposer/internal/pkg/ingresses"
+	"github.com/stakater/Xposer/internal/pkg/ownership"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func Create(routeName string, namespace string, forwardAnnotationsMap map[string]string,
 	routeHost string, routePath string, serviceName string, servicePort int) *osV1.Route {
-	return &osV1.Route{
+	route := &osV1.Route{
 		ObjectMeta: meta_v1.ObjectMeta{
 			Name:        routeName,
 			Namespace:   namespace,
@@ -26,4 +29,32 @@ func Create(routeName string, namespace string, forwardAnnotationsMap map[string
 			},
 		},
 	}
+
+	// Marks the Route as generated by Xposer for the service
+	ownership.Mark(&route.ObjectMeta, namespace, serviceName)
+	ownership.RecordIdentity(&route.ObjectMeta, "Route")
+
+	return route
+}
+
+// Diff returns the fields of the actual Route which differ from the desired one. Annotations added by others are
+// ignored, only annotations of the desired Route are compared
+func Diff(desired *osV1.Route, actual *osV1.Route) []string {
+	var drifted []string
+
+	if desired.Spec.Host != actual.Spec.Host {
+		drifted = append(drifted, "spec.host")
+	}
+	if desired.Spec.Path != actual.Spec.Path {
+		drifted = append(drifted, "spec.path")
+	}
+	// The API server defaults the weight, so only the target itself is compared
+	if desired.Spec.To.Kind != actual.Spec.To.Kind || desired.Spec.To.Name != actual.Spec.To.Name {
+		drifted = append(drifted, "spec.to")
+	}
+	if !apiequality.Semantic.DeepEqual(desired.Spec.Port, actual.Spec.Port) {
+		drifted = append(drifted, "spec.port")
+	}
+
+	return append(drifted, ingresses.DiffAnnotations(desired.Annotations, actual.Annotations)...)
 }
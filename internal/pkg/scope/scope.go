@@ -0,0 +1,116 @@
+package scope
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Scope is the set of namespaces watched by Xposer, given by their names, a label selector or both. It includes all
+// namespaces if neither is given
+type Scope struct {
+	names    map[string]bool
+	selector labels.Selector
+}
+
+// New returns the scope of the given namespaces, empty names are ignored, and of the namespaces matching the selector
+func New(names []string, selector string) (Scope, error) {
+	scope := Scope{}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if scope.names == nil {
+			scope.names = make(map[string]bool)
+		}
+		scope.names[name] = true
+	}
+
+	if selector != "" {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return Scope{}, fmt.Errorf("invalid namespace selector: %v", err)
+		}
+		scope.selector = parsed
+	}
+
+	return scope, nil
+}
+
+// Parse returns the scope of a comma separated list of namespaces and a label selector
+func Parse(names string, selector string) (Scope, error) {
+	return New(strings.Split(names, ","), selector)
+}
+
+// All returns true if all namespaces are watched
+func (s Scope) All() bool {
+	return s.names == nil && s.selector == nil
+}
+
+// Namespace returns the namespace informers are restricted to, which is the only watched namespace, or empty if they
+// must watch all namespaces and filter them with Includes
+func (s Scope) Namespace() string {
+	if len(s.names) != 1 || s.selector != nil {
+		return v1.NamespaceAll
+	}
+	for name := range s.names {
+		return name
+	}
+
+	return v1.NamespaceAll
+}
+
+// Names returns the listed namespaces, sorted, or nil if namespaces are not listed
+func (s Scope) Names() []string {
+	if s.names == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(s.names))
+	for name := range s.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NeedsNamespace returns true if the labels of a namespace must be known to decide whether it is watched
+func (s Scope) NeedsNamespace() bool {
+	return s.selector != nil
+}
+
+// Includes returns true if the named namespace is watched. The namespace is only needed with a selector, a nil
+// namespace is never selected by it
+func (s Scope) Includes(name string, namespace *v1.Namespace) bool {
+	if s.names != nil && !s.names[name] {
+		return false
+	}
+	if s.selector == nil {
+		return true
+	}
+
+	return namespace != nil && s.selector.Matches(labels.Set(namespace.Labels))
+}
+
+// String describes the scope for logs
+func (s Scope) String() string {
+	if s.All() {
+		return "all namespaces"
+	}
+
+	descriptions := []string{}
+	if s.names != nil {
+		descriptions = append(descriptions, "namespaces "+strings.Join(s.Names(), ", "))
+	}
+	if s.selector != nil {
+		descriptions = append(descriptions, "namespaces labeled "+s.selector.String())
+	}
+
+	return strings.Join(descriptions, " and ")
+}
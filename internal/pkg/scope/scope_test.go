@@ -0,0 +1,80 @@
+package scope
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestScopeIncludes(t *testing.T) {
+	newNamespace := func(name string, labels map[string]string) *v1.Namespace {
+		return &v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	tests := []struct {
+		name          string
+		names         string
+		selector      string
+		namespace     *v1.Namespace
+		want          bool
+		wantNamespace string
+	}{
+		{
+			name:      "empty scope should include all namespaces",
+			namespace: newNamespace("team", nil),
+			want:      true,
+		},
+		{
+			name:          "single namespace should restrict informers",
+			names:         "team",
+			namespace:     newNamespace("team", nil),
+			want:          true,
+			wantNamespace: "team",
+		},
+		{
+			name:      "listed namespaces should be included",
+			names:     "team, other",
+			namespace: newNamespace("other", nil),
+			want:      true,
+		},
+		{
+			name:      "namespaces which are not listed should be excluded",
+			names:     "team,other",
+			namespace: newNamespace("kube-system", nil),
+		},
+		{
+			name:      "selected namespaces should be included",
+			selector:  "xposer=enabled",
+			namespace: newNamespace("team", map[string]string{"xposer": "enabled"}),
+			want:      true,
+		},
+		{
+			name:      "listed namespaces should also be selected",
+			names:     "team",
+			selector:  "xposer=enabled",
+			namespace: newNamespace("team", nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope, err := Parse(tt.names, tt.selector)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if got := scope.Includes(tt.namespace.Name, tt.namespace); got != tt.want {
+				t.Errorf("Includes() = %v, want %v", got, tt.want)
+			}
+			if got := scope.Namespace(); got != tt.wantNamespace {
+				t.Errorf("Namespace() = %q, want %q", got, tt.wantNamespace)
+			}
+		})
+	}
+}
+
+func TestParseInvalidSelector(t *testing.T) {
+	if _, err := Parse("", "xposer in (enabled"); err == nil {
+		t.Errorf("Parse() error = nil, want an error")
+	}
+}
@@ -2,6 +2,21 @@ package services
 
 import "k8s.io/api/core/v1"
 
+// GetServicePortFromEvent returns the port which is exposed, 0 if the service has no ports
 func GetServicePortFromEvent(service *v1.Service) int {
+	if len(service.Spec.Ports) == 0 {
+		return 0
+	}
+
 	return int(service.Spec.Ports[0].Port)
 }
+
+// GetServicePortNameFromEvent returns the name of the port which is exposed, empty if the port has no name or the
+// service has no ports
+func GetServicePortNameFromEvent(service *v1.Service) string {
+	if len(service.Spec.Ports) == 0 {
+		return ""
+	}
+
+	return service.Spec.Ports[0].Name
+}
@@ -0,0 +1,103 @@
+package templates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const shortHashLength = 8
+
+var dns1123InvalidCharacters = regexp.MustCompile("[^a-z0-9-]+")
+
+// FuncMap returns the functions available in name, URL, path and secret templates, e.g.
+// {{.Service | trunc 20 | dns1123}} or {{default "stakater.com" .Domain}}
+func FuncMap() map[string]interface{} {
+	return map[string]interface{}{
+		"lower":        strings.ToLower,
+		"upper":        strings.ToUpper,
+		"trunc":        trunc,
+		"replace":      replace,
+		"regexReplace": regexReplace,
+		"sha256":       sha256Sum,
+		"shortHash":    shortHash,
+		"default":      defaultValue,
+		"dns1123":      ToDNS1123Label,
+	}
+}
+
+// trunc keeps the first length characters of value, or the last ones if length is negative
+func trunc(length int, value string) string {
+	if length < 0 && len(value) > -length {
+		return value[len(value)+length:]
+	}
+	if length >= 0 && len(value) > length {
+		return value[:length]
+	}
+
+	return value
+}
+
+// replace replaces all occurrences of old in value with new
+func replace(old string, new string, value string) string {
+	return strings.Replace(value, old, new, -1)
+}
+
+// regexReplace replaces all matches of expression in value with replacement, which can refer to groups as $1
+func regexReplace(expression string, replacement string, value string) (string, error) {
+	regex, err := regexp.Compile(expression)
+	if err != nil {
+		return "", err
+	}
+
+	return regex.ReplaceAllString(value, replacement), nil
+}
+
+// sha256Sum returns the hex encoded sha256 hash of value
+func sha256Sum(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])
+}
+
+// shortHash returns the first characters of the sha256 hash of value, to make truncated names unique
+func shortHash(value string) string {
+	return sha256Sum(value)[:shortHashLength]
+}
+
+// defaultValue returns value, or defaultVal if value is empty
+func defaultValue(defaultVal interface{}, value interface{}) interface{} {
+	if value == nil {
+		return defaultVal
+	}
+
+	reflectValue := reflect.ValueOf(value)
+	switch reflectValue.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if reflectValue.Len() == 0 {
+			return defaultVal
+		}
+	case reflect.Ptr, reflect.Interface:
+		if reflectValue.IsNil() {
+			return defaultVal
+		}
+	}
+
+	return value
+}
+
+// ToDNS1123Label turns value into a valid DNS-1123 label, by lower casing it, replacing invalid characters with -
+// and truncating it to 63 characters
+func ToDNS1123Label(value string) string {
+	label := dns1123InvalidCharacters.ReplaceAllString(strings.ToLower(value), "-")
+	label = strings.Trim(label, "-")
+
+	if len(label) > validation.DNS1123LabelMaxLength {
+		label = strings.TrimRight(label[:validation.DNS1123LabelMaxLength], "-")
+	}
+
+	return label
+}
@@ -0,0 +1,132 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFuncMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{
+			name:     "lower should lower case",
+			template: "{{lower .Service}}",
+			want:     "my-service",
+		},
+		{
+			name:     "upper should upper case",
+			template: "{{upper .Namespace}}",
+			want:     "DEFAULT",
+		},
+		{
+			name:     "trunc should keep the first characters",
+			template: "{{trunc 2 .Namespace}}",
+			want:     "de",
+		},
+		{
+			name:     "trunc with negative length should keep the last characters",
+			template: "{{trunc -3 .Namespace}}",
+			want:     "ult",
+		},
+		{
+			name:     "trunc should keep shorter values",
+			template: "{{trunc 20 .Namespace}}",
+			want:     "default",
+		},
+		{
+			name:     "replace should replace all occurrences",
+			template: "{{replace \"-\" \".\" .Service}}",
+			want:     "My.Service",
+		},
+		{
+			name:     "regexReplace should replace matches",
+			template: "{{regexReplace \"^(.*)-Service$\" \"$1\" .Service}}",
+			want:     "My",
+		},
+		{
+			name:     "sha256 should hash",
+			template: "{{sha256 .Namespace}}",
+			want:     "37a8eec1ce19687d132fe29051dca629d164e2c4958ba141d5f4133a33f0688f",
+		},
+		{
+			name:     "shortHash should keep the start of the hash",
+			template: "{{shortHash .Namespace}}",
+			want:     "37a8eec1",
+		},
+		{
+			name:     "default should be used for empty values",
+			template: "{{default \"fallback\" .Domain}}",
+			want:     "fallback",
+		},
+		{
+			name:     "default should not be used for set values",
+			template: "{{default \"fallback\" .Namespace}}",
+			want:     "default",
+		},
+		{
+			name:     "dns1123 should sanitize",
+			template: "{{dns1123 .Service}}",
+			want:     "my-service",
+		},
+		{
+			name:     "functions should be chainable",
+			template: "{{.Service | lower | trunc 2}}-{{shortHash .Service}}",
+			want:     "my-" + shortHash("My-Service"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render("test", tt.template, CreateUrlTemplate(ServiceVariables{Service: "My-Service", Namespace: "default"}, ""))
+			if err != nil {
+				t.Errorf("Render() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToDNS1123Label(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{
+			name:  "valid label should be kept",
+			value: "my-service",
+			want:  "my-service",
+		},
+		{
+			name:  "invalid characters should be replaced",
+			value: "My_Service.v1",
+			want:  "my-service-v1",
+		},
+		{
+			name:  "leading and trailing dashes should be removed",
+			value: "_my-service_",
+			want:  "my-service",
+		},
+		{
+			name:  "long values should be truncated",
+			value: strings.Repeat("a", 70),
+			want:  strings.Repeat("a", 63),
+		},
+		{
+			name:  "truncation should not leave a trailing dash",
+			value: strings.Repeat("a", 62) + "-b",
+			want:  strings.Repeat("a", 62),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToDNS1123Label(tt.value); got != tt.want {
+				t.Errorf("ToDNS1123Label() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
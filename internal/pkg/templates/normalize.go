@@ -0,0 +1,63 @@
+package templates
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NormalizeHost turns a rendered host into a valid DNS-1123 subdomain whose labels fit in DNS, see normalize
+func NormalizeHost(host string) string {
+	return normalize(host, validation.DNS1123LabelMaxLength)
+}
+
+// NormalizeName turns a rendered object name into a valid DNS-1123 subdomain, see normalize. Unlike hosts, the
+// labels of names are only limited by the length of the whole name
+func NormalizeName(name string) string {
+	return normalize(name, validation.DNS1123SubdomainMaxLength)
+}
+
+// normalize lower cases value, replaces invalid characters with -, drops empty labels and truncates every label to
+// labelMaxLength characters. If the result is still longer than a subdomain may be, the leftmost labels, which are
+// the most specific ones of a host, are shortened. Truncated labels end with a hash of the whole label, so that values
+// sharing a long prefix stay unique and the same value is always normalized the same way. Valid values are kept
+func normalize(value string, labelMaxLength int) string {
+	labels := []string{}
+	for _, label := range strings.Split(strings.ToLower(value), ".") {
+		label = strings.Trim(dns1123InvalidCharacters.ReplaceAllString(label, "-"), "-")
+		if label != "" {
+			labels = append(labels, truncateWithHash(label, labelMaxLength))
+		}
+	}
+
+	for i := range labels {
+		excess := len(strings.Join(labels, ".")) - validation.DNS1123SubdomainMaxLength
+		if excess <= 0 {
+			break
+		}
+		labels[i] = truncateWithHash(labels[i], len(labels[i])-excess)
+	}
+
+	return strings.Join(labels, ".")
+}
+
+// truncateWithHash truncates label to maxLength characters, replacing its end with a short hash of the whole label.
+// Without room for a prefix and the -, only the hash, or as much of it as fits, is kept. A label is never empty
+func truncateWithHash(label string, maxLength int) string {
+	if len(label) <= maxLength {
+		return label
+	}
+
+	hash := shortHash(label)
+	if maxLength < len(hash)+2 {
+		if maxLength < 1 {
+			maxLength = 1
+		}
+		if maxLength > len(hash) {
+			maxLength = len(hash)
+		}
+		return hash[:maxLength]
+	}
+
+	return strings.TrimRight(label[:maxLength-len(hash)-1], "-") + "-" + hash
+}
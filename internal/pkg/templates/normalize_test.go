@@ -0,0 +1,191 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestNormalizeHost(t *testing.T) {
+	longLabel := strings.Repeat("a", 70)
+	longHost := strings.Repeat(strings.Repeat("b", 60)+".", 5) + "example.com"
+
+	tests := []struct {
+		name  string
+		host  string
+		want  string
+		check func(t *testing.T, got string)
+	}{
+		{
+			name: "valid host should be kept",
+			host: "my-service.default.example.com",
+			want: "my-service.default.example.com",
+		},
+		{
+			name: "upper case should be lowered",
+			host: "My-Service.Default.example.com",
+			want: "my-service.default.example.com",
+		},
+		{
+			name: "invalid characters should be replaced",
+			host: "my_service.team a.example.com",
+			want: "my-service.team-a.example.com",
+		},
+		{
+			name: "labels should not start or end with -",
+			host: "_my-service_.-team-.example.com",
+			want: "my-service.team.example.com",
+		},
+		{
+			name: "empty labels should be dropped",
+			host: ".my-service..__.example.com.",
+			want: "my-service.example.com",
+		},
+		{
+			name: "label longer than 63 characters should be truncated with a hash",
+			host: longLabel + ".example.com",
+			want: strings.Repeat("a", 54) + "-" + shortHash(longLabel) + ".example.com",
+		},
+		{
+			name: "label of exactly 63 characters should be kept",
+			host: strings.Repeat("a", 63) + ".example.com",
+			want: strings.Repeat("a", 63) + ".example.com",
+		},
+		{
+			name: "host longer than 253 characters should be shortened from the left",
+			host: longHost,
+			check: func(t *testing.T, got string) {
+				if len(got) > validation.DNS1123SubdomainMaxLength {
+					t.Errorf("NormalizeHost() length = %d", len(got))
+				}
+				if !strings.HasSuffix(got, "."+strings.Repeat("b", 60)+".example.com") {
+					t.Errorf("NormalizeHost() = %v, should keep the rightmost labels", got)
+				}
+			},
+		},
+		{
+			name: "no valid characters should give an empty host",
+			host: "_._",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeHost(tt.host)
+			if tt.check != nil {
+				tt.check(t, got)
+			} else if got != tt.want {
+				t.Errorf("NormalizeHost() = %v, want %v", got, tt.want)
+			}
+			if got != "" {
+				if errs := validation.IsDNS1123Subdomain(got); len(errs) > 0 {
+					t.Errorf("NormalizeHost() = %v, is invalid: %v", got, errs)
+				}
+			}
+			if again := NormalizeHost(got); again != got {
+				t.Errorf("NormalizeHost() is not stable, %v became %v", got, again)
+			}
+		})
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantLen int
+		want    string
+		check   func(t *testing.T, got string)
+	}{
+		{
+			name:  "long labels should be kept in names",
+			value: strings.Repeat("a", 70),
+			want:  strings.Repeat("a", 70),
+		},
+		{
+			name:  "invalid characters should be replaced",
+			value: "My_Service-Team_A",
+			want:  "my-service-team-a",
+		},
+		{
+			name:    "name longer than 253 characters should be truncated with a hash",
+			value:   strings.Repeat("a", 300),
+			wantLen: validation.DNS1123SubdomainMaxLength,
+			want:    strings.Repeat("a", 244) + "-" + shortHash(strings.Repeat("a", 300)),
+		},
+		{
+			name:  "many long labels should not panic",
+			value: "a." + strings.TrimSuffix(strings.Repeat(strings.Repeat("b", 63)+".", 29), "."),
+			check: func(t *testing.T, got string) {
+				if len(got) > validation.DNS1123SubdomainMaxLength || len(validation.IsDNS1123Subdomain(got)) != 0 {
+					t.Errorf("NormalizeName() = %v, want a valid name", got)
+				}
+			},
+		},
+		{
+			name:  "different long names with the same prefix should stay unique",
+			value: strings.Repeat("a", 260) + "b",
+			want:  strings.Repeat("a", 244) + "-" + shortHash(strings.Repeat("a", 260)+"b"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeName(tt.value)
+			if tt.check != nil {
+				tt.check(t, got)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeName() = %v, want %v", got, tt.want)
+			}
+			if tt.wantLen != 0 && len(got) != tt.wantLen {
+				t.Errorf("NormalizeName() length = %d, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestTruncateWithHash(t *testing.T) {
+	label := strings.Repeat("b", 63)
+	hash := shortHash(label)
+
+	tests := []struct {
+		name      string
+		maxLength int
+		want      string
+	}{
+		{
+			name:      "no room should keep one character of the hash",
+			maxLength: 0,
+			want:      hash[:1],
+		},
+		{
+			name:      "one character should be the start of the hash",
+			maxLength: 1,
+			want:      hash[:1],
+		},
+		{
+			name:      "room for the hash only should keep the hash",
+			maxLength: len(hash),
+			want:      hash,
+		},
+		{
+			name:      "room for the hash and the separator only should keep the hash",
+			maxLength: len(hash) + 1,
+			want:      hash,
+		},
+		{
+			name:      "room for one character should keep a prefix",
+			maxLength: len(hash) + 2,
+			want:      "b-" + hash,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateWithHash(label, tt.maxLength); got != tt.want {
+				t.Errorf("truncateWithHash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
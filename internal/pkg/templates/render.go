@@ -0,0 +1,65 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RenderError is returned when a template can not be rendered. Exposing the service is blocked until the template
+// or the service is fixed, instead of generating an object with an empty or mangled value
+type RenderError struct {
+	Name     string
+	Template string
+	Reason   string
+}
+
+func (e *RenderError) Error() string {
+	return fmt.Sprintf("can not render %v %q: %v", e.Name, e.Template, e.Reason)
+}
+
+// Parse parses a template with the functions of FuncMap, executing it fails if it reads a missing map key
+func Parse(name string, templateToParse string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap(FuncMap())).Parse(templateToParse)
+	if err != nil {
+		return nil, &RenderError{Name: name, Template: templateToParse, Reason: err.Error()}
+	}
+
+	return tmpl, nil
+}
+
+// Execute executes a parsed template against the given data, rendering an empty value is an error
+func Execute(tmpl *template.Template, templateToParse string, data interface{}) (string, error) {
+	var parsedTemplate bytes.Buffer
+
+	err := tmpl.Execute(&parsedTemplate, data)
+	if err != nil {
+		return "", &RenderError{Name: tmpl.Name(), Template: templateToParse, Reason: err.Error()}
+	}
+
+	if parsedTemplate.Len() == 0 {
+		return "", &RenderError{Name: tmpl.Name(), Template: templateToParse, Reason: "rendered to an empty value"}
+	}
+
+	return parsedTemplate.String(), nil
+}
+
+// Render parses and executes a template, it is the single way names, hosts, paths and secrets are rendered
+func Render(name string, templateToParse string, data interface{}) (string, error) {
+	logrus.Infof("Template to parse: %v", templateToParse)
+
+	tmpl, err := Parse(name, templateToParse)
+	if err != nil {
+		return "", err
+	}
+
+	rendered, err := Execute(tmpl, templateToParse, data)
+	if err != nil {
+		return "", err
+	}
+	logrus.Infof("Parsed template: %v", rendered)
+
+	return rendered, nil
+}
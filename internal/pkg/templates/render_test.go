@@ -0,0 +1,74 @@
+package templates
+
+import (
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	variables := ServiceVariables{
+		Service:     "my-service",
+		Namespace:   "default",
+		Labels:      map[string]string{"team": "platform"},
+		Annotations: map[string]string{},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "values should not be HTML escaped",
+			template: "/{{.Service}}?a=1&b='2'",
+			want:     "/my-service?a=1&b='2'",
+		},
+		{
+			name:     "existing label should be rendered",
+			template: "{{.Service}}.{{.Labels.team}}",
+			want:     "my-service.platform",
+		},
+		{
+			name:     "missing label should be an error",
+			template: "{{.Service}}.{{.Labels.missing}}",
+			wantErr:  true,
+		},
+		{
+			name:     "missing label with index and default should use the default",
+			template: "{{.Service}}.{{index .Labels \"missing\" | default \"none\"}}",
+			want:     "my-service.none",
+		},
+		{
+			name:     "unknown variable should be an error",
+			template: "{{.Servce}}",
+			wantErr:  true,
+		},
+		{
+			name:     "unparsable template should be an error",
+			template: "{{.Service",
+			wantErr:  true,
+		},
+		{
+			name:     "empty value should be an error",
+			template: "{{.Domain}}",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render("test", tt.template, CreateUrlTemplate(variables, ""))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Render() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				if _, ok := err.(*RenderError); !ok {
+					t.Errorf("Render() error type = %T, want *RenderError", err)
+				}
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
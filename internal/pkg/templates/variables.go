@@ -0,0 +1,51 @@
+package templates
+
+import (
+	"github.com/stakater/Xposer/internal/pkg/services"
+	v1 "k8s.io/api/core/v1"
+)
+
+// ServiceVariables are the variables about the exposed service which are available in all templates
+type ServiceVariables struct {
+	Service         string
+	Namespace       string
+	Labels          map[string]string
+	Annotations     map[string]string
+	NamespaceLabels map[string]string
+	// NamespaceAnnotations are only template inputs, config annotations of the namespace do not override settings
+	NamespaceAnnotations map[string]string
+	ClusterName          string
+	Port                 int
+	PortName             string
+}
+
+// CreateServiceVariables creates the template variables for a service, namespace can be nil if it is not known
+func CreateServiceVariables(service *v1.Service, namespace *v1.Namespace, clusterName string) ServiceVariables {
+	variables := ServiceVariables{
+		Service:              service.Name,
+		Namespace:            service.Namespace,
+		Labels:               copyMap(service.Labels),
+		Annotations:          copyMap(service.Annotations),
+		NamespaceLabels:      map[string]string{},
+		NamespaceAnnotations: map[string]string{},
+		ClusterName:          clusterName,
+		Port:                 services.GetServicePortFromEvent(service),
+		PortName:             services.GetServicePortNameFromEvent(service),
+	}
+
+	if namespace != nil {
+		variables.NamespaceLabels = copyMap(namespace.Labels)
+		variables.NamespaceAnnotations = copyMap(namespace.Annotations)
+	}
+
+	return variables
+}
+
+func copyMap(source map[string]string) map[string]string {
+	copied := make(map[string]string, len(source))
+	for key, value := range source {
+		copied[key] = value
+	}
+
+	return copied
+}
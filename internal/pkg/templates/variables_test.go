@@ -0,0 +1,71 @@
+package templates
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreateServiceVariables(t *testing.T) {
+	service := &v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "my-service",
+			Namespace:   "my-namespace",
+			Labels:      map[string]string{"team": "platform"},
+			Annotations: map[string]string{"owner": "stakater"},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				v1.ServicePort{Name: "http", Port: 8080},
+			},
+		},
+	}
+	namespace := &v1.Namespace{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:   "my-namespace",
+			Labels: map[string]string{"env": "prod"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		namespace *v1.Namespace
+		template  string
+		want      string
+	}{
+		{
+			name:      "all variables should be available",
+			namespace: namespace,
+			template:  "{{.Service}}.{{.Labels.team}}.{{.NamespaceLabels.env}}.{{.ClusterName}}.{{.Domain}}/{{.Annotations.owner}}/{{.PortName}}/{{.Port}}",
+			want:      "my-service.platform.prod.cluster.stakater.com/stakater/http/8080",
+		},
+		{
+			name:      "unknown namespace should have no labels",
+			namespace: nil,
+			template:  "{{len .NamespaceLabels}}",
+			want:      "0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variables := CreateServiceVariables(service, tt.namespace, "cluster")
+			got, err := ParseIngressURLOrPathTemplate("test", tt.template, CreateUrlTemplate(variables, "stakater.com"))
+			if err != nil {
+				t.Errorf("ParseIngressURLOrPathTemplate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseIngressURLOrPathTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateServiceVariablesWithoutPorts(t *testing.T) {
+	service := &v1.Service{ObjectMeta: meta_v1.ObjectMeta{Name: "headless", Namespace: "my-namespace"}}
+
+	variables := CreateServiceVariables(service, nil, "cluster")
+	if variables.Port != 0 || variables.PortName != "" {
+		t.Errorf("CreateServiceVariables() port = %v and name = %q, want no port", variables.Port, variables.PortName)
+	}
+}
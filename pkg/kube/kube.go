@@ -38,6 +38,23 @@ func GetClientOutOfCluster() kubernetes.Interface {
 	return clientset
 }
 
+// GetConfig returns the config to connect to the cluster. Without an explicit kubeconfig or context the in-cluster
+// config is used if available, otherwise the config is loaded from KUBECONFIG or ~/.kube/config
+func GetConfig(kubeconfigPath string, context string) (*rest.Config, error) {
+	if kubeconfigPath == "" && context == "" {
+		config, err := rest.InClusterConfig()
+		if err == nil {
+			return config, nil
+		}
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfigPath
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: context}
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+}
+
 func buildOutOfClusterConfig() (*rest.Config, error) {
 	kubeconfigPath := os.Getenv("KUBECONFIG")
 	if kubeconfigPath == "" {